@@ -0,0 +1,182 @@
+package debuggo
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrClosed is returned when writing to an AsyncWriter that has been closed.
+var ErrClosed = errors.New("debuggo: writer closed")
+
+// DropPolicy decides what an AsyncWriter does when its queue is full.
+type DropPolicy int
+
+const (
+	// Block makes the writer wait until there is room in the queue.
+	// No records are lost, but a slow sink can still stall callers.
+	Block DropPolicy = iota
+	// DropOldest discards the oldest queued record to make room.
+	DropOldest
+	// DropNewest discards the record being written.
+	DropNewest
+)
+
+// AsyncWriter queues writes in a bounded ring buffer and writes them to an
+// underlying writer from a background goroutine, so debug calls on hot paths
+// never wait on a slow sink such as a full stderr pipe.
+//
+// Each Write is treated as one record and queued as a copy, which matches how
+// loggers write exactly one formatted line per call. When the queue is full,
+// the Policy decides whether to block or which record to drop; dropped records
+// are counted and reported by Dropped.
+//
+// Call Flush to wait for queued records to be written, and Close during
+// shutdown so nothing is lost.
+//
+// Example:
+//
+//	aw := debuggo.NewAsyncWriter(os.Stderr, 1024, debuggo.DropOldest)
+//	debuggo.SetOutput(aw)
+//	defer aw.Close()
+type AsyncWriter struct {
+	w      io.Writer
+	policy DropPolicy
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   [][]byte
+	head    int
+	count   int
+	busy    bool
+	closed  bool
+	dropped uint64
+	done    chan struct{}
+}
+
+// NewAsyncWriter starts an AsyncWriter that writes to w, queueing at most
+// size records. A size below 1 is treated as 1.
+func NewAsyncWriter(w io.Writer, size int, policy DropPolicy) *AsyncWriter {
+	if size < 1 {
+		size = 1
+	}
+
+	aw := &AsyncWriter{
+		w:      w,
+		policy: policy,
+		queue:  make([][]byte, size),
+		done:   make(chan struct{}),
+	}
+	aw.cond = sync.NewCond(&aw.mu)
+
+	go aw.run()
+	return aw
+}
+
+// Write implements the io.Writer interface by queueing a copy of p.
+// It reports len(p) bytes written even if the record is dropped, and
+// returns ErrClosed after Close.
+func (aw *AsyncWriter) Write(p []byte) (n int, err error) {
+	record := make([]byte, len(p))
+	copy(record, p)
+
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+
+	if aw.closed {
+		return 0, ErrClosed
+	}
+
+	if aw.count == len(aw.queue) {
+		switch aw.policy {
+		case DropNewest:
+			aw.dropped++
+			return len(p), nil
+		case DropOldest:
+			aw.queue[aw.head] = nil
+			aw.head = (aw.head + 1) % len(aw.queue)
+			aw.count--
+			aw.dropped++
+		default:
+			for aw.count == len(aw.queue) && !aw.closed {
+				aw.cond.Wait()
+			}
+			if aw.closed {
+				return 0, ErrClosed
+			}
+		}
+	}
+
+	aw.queue[(aw.head+aw.count)%len(aw.queue)] = record
+	aw.count++
+	aw.cond.Broadcast()
+	return len(p), nil
+}
+
+// Flush blocks until every record queued so far has been written to the
+// underlying writer.
+func (aw *AsyncWriter) Flush() {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+
+	for aw.count > 0 || aw.busy {
+		aw.cond.Wait()
+	}
+}
+
+// Close flushes any queued records and stops the background goroutine.
+// Writes after Close return ErrClosed. If the underlying writer is an
+// io.Closer it is not closed; that remains the caller's responsibility.
+func (aw *AsyncWriter) Close() error {
+	aw.mu.Lock()
+	if !aw.closed {
+		aw.closed = true
+		aw.cond.Broadcast()
+	}
+	aw.mu.Unlock()
+
+	<-aw.done
+	return nil
+}
+
+// Dropped returns the number of records discarded because the queue was full.
+func (aw *AsyncWriter) Dropped() uint64 {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+	return aw.dropped
+}
+
+// run drains the queue until the writer is closed and empty.
+func (aw *AsyncWriter) run() {
+	defer close(aw.done)
+
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+
+	for {
+		for aw.count == 0 && !aw.closed {
+			aw.cond.Wait()
+		}
+		if aw.count == 0 {
+			// Closed and fully drained
+			aw.cond.Broadcast()
+			return
+		}
+
+		record := aw.queue[aw.head]
+		aw.queue[aw.head] = nil
+		aw.head = (aw.head + 1) % len(aw.queue)
+		aw.count--
+		aw.busy = true
+		// Wake writers blocked on a full queue
+		aw.cond.Broadcast()
+
+		// Write without holding the lock so callers can keep queueing
+		aw.mu.Unlock()
+		aw.w.Write(record)
+		aw.mu.Lock()
+
+		aw.busy = false
+		aw.cond.Broadcast()
+	}
+}
@@ -0,0 +1,173 @@
+package debuggo
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedWriter blocks every write until released, so tests can hold the
+// AsyncWriter's background goroutine inside a write while filling the queue.
+type gatedWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	started chan struct{}
+	release chan struct{}
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{
+		started: make(chan struct{}, 100),
+		release: make(chan struct{}),
+	}
+}
+
+func (g *gatedWriter) Write(p []byte) (int, error) {
+	g.started <- struct{}{}
+	<-g.release
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.buf.Write(p)
+}
+
+func (g *gatedWriter) String() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.buf.String()
+}
+
+func TestAsyncWriterWritesInOrder(t *testing.T) {
+	buf := &syncBuffer{}
+	aw := NewAsyncWriter(buf, 4, Block)
+
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(aw, "%d\n", i)
+	}
+	aw.Flush()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 100 {
+		t.Fatalf("Expected 100 lines, got %d", len(lines))
+	}
+	for i, line := range lines {
+		if line != fmt.Sprint(i) {
+			t.Fatalf("Expected line %d to be %d, got %s", i, i, line)
+		}
+	}
+
+	if err := aw.Close(); err != nil {
+		t.Errorf("Expected no error from Close, got %v", err)
+	}
+	if _, err := aw.Write([]byte("late\n")); err != ErrClosed {
+		t.Errorf("Expected ErrClosed after Close, got %v", err)
+	}
+}
+
+func TestAsyncWriterDropPolicies(t *testing.T) {
+	testCases := []struct {
+		policy      DropPolicy
+		expected    string
+		dropped     uint64
+		description string
+	}{
+		{DropNewest, "1,2,3,", 1, "DropNewest discards the incoming record"},
+		{DropOldest, "1,3,4,", 1, "DropOldest discards the oldest queued record"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			gw := newGatedWriter()
+			aw := NewAsyncWriter(gw, 2, tc.policy)
+
+			// Hold the background goroutine inside the write of "1"
+			aw.Write([]byte("1,"))
+			<-gw.started
+
+			// Fill the queue, then overflow it
+			aw.Write([]byte("2,"))
+			aw.Write([]byte("3,"))
+			aw.Write([]byte("4,"))
+
+			close(gw.release)
+			aw.Close()
+
+			if gw.String() != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, gw.String())
+			}
+			if aw.Dropped() != tc.dropped {
+				t.Errorf("Expected %d dropped, got %d", tc.dropped, aw.Dropped())
+			}
+		})
+	}
+}
+
+func TestAsyncWriterBlockPolicy(t *testing.T) {
+	gw := newGatedWriter()
+	aw := NewAsyncWriter(gw, 1, Block)
+
+	aw.Write([]byte("1,"))
+	<-gw.started
+	aw.Write([]byte("2,"))
+
+	written := make(chan struct{})
+	go func() {
+		aw.Write([]byte("3,"))
+		close(written)
+	}()
+
+	select {
+	case <-written:
+		t.Fatal("Expected Write to block while the queue is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(gw.release)
+	<-written
+	aw.Close()
+
+	if gw.String() != "1,2,3," {
+		t.Errorf("Expected nothing to be dropped, got %q", gw.String())
+	}
+	if aw.Dropped() != 0 {
+		t.Errorf("Expected 0 dropped, got %d", aw.Dropped())
+	}
+}
+
+func TestAsyncWriterWithLogger(t *testing.T) {
+	os.Setenv("DEBUG", "app")
+	ReloadDebugSettings()
+
+	buf := &syncBuffer{}
+	aw := NewAsyncWriter(buf, 16, Block)
+	defer aw.Close()
+
+	New("app").WithOutput(aw).Printf("queued %d", 1)
+	aw.Flush()
+
+	if !strings.HasSuffix(buf.String(), " app queued 1\n") {
+		t.Errorf("Expected flushed debug line, got %q", buf.String())
+	}
+}
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,126 @@
+package debuggo
+
+import (
+	"context"
+	"sync"
+)
+
+// contextKey is the key under which debug fields are stored in a context.
+type contextKey struct{}
+
+// ContextHook is called for every line logged through a context-aware debug
+// function (DebugContext or Logger.PrintfContext) whose namespace is enabled.
+// It receives the context and the record before formatting, and may append
+// fields to r.Fields or observe the line, e.g. to correlate it with a trace.
+// Hooks run on the logging goroutine and must be safe for concurrent use.
+type ContextHook func(ctx context.Context, r *Record)
+
+// hookEntry wraps a ContextHook so it can be removed by identity.
+type hookEntry struct {
+	hook ContextHook
+}
+
+var (
+	contextHooks []*hookEntry
+	hooksMu      sync.RWMutex
+)
+
+// AddContextHook registers a hook to run for context-aware debug lines and
+// returns a function that removes it again. Hooks run in the order they were
+// added. Integrations such as the debuggootel package use this to attach
+// tracing data without the core package depending on them.
+func AddContextHook(h ContextHook) (remove func()) {
+	entry := &hookEntry{hook: h}
+
+	hooksMu.Lock()
+	// Copy on write so runContextHooks can iterate without holding the lock
+	hooks := make([]*hookEntry, 0, len(contextHooks)+1)
+	hooks = append(hooks, contextHooks...)
+	contextHooks = append(hooks, entry)
+	hooksMu.Unlock()
+
+	return func() {
+		hooksMu.Lock()
+		defer hooksMu.Unlock()
+		hooks := make([]*hookEntry, 0, len(contextHooks))
+		for _, e := range contextHooks {
+			if e != entry {
+				hooks = append(hooks, e)
+			}
+		}
+		contextHooks = hooks
+	}
+}
+
+// runContextHooks passes r through every registered hook.
+func runContextHooks(ctx context.Context, r *Record) {
+	hooksMu.RLock()
+	hooks := contextHooks
+	hooksMu.RUnlock()
+
+	for _, e := range hooks {
+		e.hook(ctx, r)
+	}
+}
+
+// WithContext returns a copy of ctx carrying the given fields in addition to
+// any already stored in ctx. Debug lines logged with a context-aware function
+// (DebugContext or Logger.PrintfContext) include these fields, so request and
+// trace IDs only need to be attached once, where the request enters the
+// service.
+//
+// Example:
+//
+//	func handler(w http.ResponseWriter, r *http.Request) {
+//	    ctx := debuggo.WithContext(r.Context(), debuggo.F("request_id", r.Header.Get("X-Request-ID")))
+//	    process(ctx)
+//	}
+func WithContext(ctx context.Context, fields ...Field) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+
+	parent := FromContext(ctx)
+
+	// Always copy so that sibling contexts never share a backing array
+	merged := make([]Field, 0, len(parent)+len(fields))
+	merged = append(merged, parent...)
+	merged = append(merged, fields...)
+	return context.WithValue(ctx, contextKey{}, merged)
+}
+
+// FromContext returns the debug fields stored in ctx by WithContext, or nil
+// if there are none. The returned slice must not be modified.
+func FromContext(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(contextKey{}).([]Field)
+	return fields
+}
+
+// DebugContext is like Debug, but the returned function takes a context and
+// appends the fields stored in it by WithContext to every line. Fields are
+// only looked up when the module is enabled.
+//
+// Example:
+//
+//	var debug = debuggo.DebugContext("app:server:http")
+//
+//	debug(ctx, "Handling %s", r.URL.Path)
+//
+// Output:
+//
+//	12:34:56.789 app:server:http Handling /api/users request_id=42
+func DebugContext(module string) func(ctx context.Context, format string, args ...interface{}) {
+	return New(module).PrintfContext
+}
+
+// PrintfContext is like Printf, but appends the fields stored in ctx by
+// WithContext to the line and runs any registered context hooks.
+func (l *Logger) PrintfContext(ctx context.Context, format string, args ...interface{}) {
+	if h := l.helper(); h != nil {
+		h.Helper()
+	}
+	l.log(ctx, message{format: format, args: args})
+}
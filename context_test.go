@@ -0,0 +1,100 @@
+package debuggo
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWithContextMergesFields(t *testing.T) {
+	ctx := WithContext(context.Background(), F("request_id", 42))
+	child := WithContext(ctx, F("trace_id", "abc"))
+	sibling := WithContext(ctx, F("user", "bob"))
+
+	fields := FromContext(child)
+	if len(fields) != 2 || fields[0].Key != "request_id" || fields[1].Key != "trace_id" {
+		t.Errorf("Expected parent then child fields, got %v", fields)
+	}
+
+	// Siblings must not see each other's fields
+	fields = FromContext(sibling)
+	if len(fields) != 2 || fields[1].Key != "user" {
+		t.Errorf("Expected sibling fields to be independent, got %v", fields)
+	}
+
+	if FromContext(context.Background()) != nil {
+		t.Error("Expected no fields in a bare context")
+	}
+}
+
+func TestDebugContext(t *testing.T) {
+	os.Setenv("DEBUG", "app:*")
+	ReloadDebugSettings()
+
+	buf := &bytes.Buffer{}
+	SetOutput(buf)
+	defer SetOutput(nil)
+
+	ctx := WithContext(context.Background(), F("request_id", 42), F("path", "/api/users"))
+
+	debug := DebugContext("app:http")
+	debug(ctx, "Handling %s", "request")
+
+	expected := " app:http Handling request request_id=42 path=/api/users\n"
+	if !strings.HasSuffix(buf.String(), expected) {
+		t.Errorf("Expected output ending in %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	DebugContext("other")(ctx, "Hidden")
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output for disabled namespace, got %q", buf.String())
+	}
+}
+
+func TestContextHooks(t *testing.T) {
+	os.Setenv("DEBUG", "app")
+	ReloadDebugSettings()
+
+	buf := &bytes.Buffer{}
+	l := New("app").WithOutput(buf)
+
+	type hookKey struct{}
+	removeFirst := AddContextHook(func(ctx context.Context, r *Record) {
+		if v := ctx.Value(hookKey{}); v != nil {
+			r.Fields = append(r.Fields, F("hooked", v))
+		}
+	})
+	removeSecond := AddContextHook(func(ctx context.Context, r *Record) {
+		r.Fields = append(r.Fields, F("order", len(r.Fields)))
+	})
+
+	base := WithContext(context.Background(), F("request_id", 1))
+	ctx := context.WithValue(base, hookKey{}, "yes")
+	l.PrintfContext(ctx, "first")
+
+	if !strings.HasSuffix(buf.String(), " app first request_id=1 hooked=yes order=2\n") {
+		t.Errorf("Expected hooks to run in order, got %q", buf.String())
+	}
+
+	// Hooks must not leak fields into the context's own slice
+	if len(FromContext(ctx)) != 1 {
+		t.Errorf("Expected context fields to be unchanged, got %v", FromContext(ctx))
+	}
+
+	removeFirst()
+	buf.Reset()
+	l.PrintfContext(ctx, "second")
+	if !strings.HasSuffix(buf.String(), " app second request_id=1 order=1\n") {
+		t.Errorf("Expected removed hook to stop running, got %q", buf.String())
+	}
+
+	removeSecond()
+	buf.Reset()
+	l.PrintfContext(ctx, "third")
+	if !strings.HasSuffix(buf.String(), " app third request_id=1\n") {
+		t.Errorf("Expected no hooks to run, got %q", buf.String())
+	}
+}
@@ -27,6 +27,16 @@
 //	DEBUG=myapp:* # Enable all myapp namespace messages
 //	DEBUG=*,!verbose # Enable all except verbose namespace
 //	DEBUG=app:*,!app:db # Enable all app components except database
+//	DEBUG='app:*,app:http@rate=10/s' # Enable app, limiting app:http to 10 lines a second
+//
+// # File Output
+//
+// Set DEBUG_FILE to write debug output to a rotating file instead of stderr
+// (see RotatingFile). The limits can be tuned with DEBUG_FILE_MAX_SIZE
+// (bytes, with optional K, M or G suffix), DEBUG_FILE_MAX_AGE (a duration such
+// as 24h), DEBUG_FILE_MAX_BACKUPS and DEBUG_FILE_COMPRESS:
+//
+//	DEBUG=* DEBUG_FILE=/var/log/app-debug.log DEBUG_FILE_MAX_SIZE=50M ./app
 //
 // # Advanced Usage
 //
@@ -34,31 +44,36 @@
 package debuggo
 
 import (
-	"fmt"
 	"os"
 	"strings"
 	"sync"
-	"time"
 )
 
 var (
+	activeSpec    = &namespaceSpec{}
+	debugMu       sync.RWMutex
+	isInitialized bool
+)
+
+// namespaceSpec is a parsed DEBUG value: the set of enabled and negated
+// namespace patterns.
+type namespaceSpec struct {
+	raw             string
 	debugNamespaces map[string]bool
 	negatedModules  map[string]bool
 	wildcardEnabled bool
-	debugMu         sync.RWMutex
-	isInitialized   bool
-)
+	// limits holds the rules from entries with @ options, in order
+	limits []*limitRule
+}
 
 func init() {
 	parseDebugEnv()
+	configureDebugFile()
+	configureGoroutineFields()
 }
 
 // parseDebugEnv parses the DEBUG environment variable to determine which modules to log.
-// Format: DEBUG=namespace1,namespace2:*,!namespace3
-// - Use comma to separate multiple namespaces
-// - Use * as wildcard for all namespaces
-// - Prefix with ! to negate a namespace
-// - Use colon (:) for hierarchical namespaces
+// See parseSpec for the format.
 func parseDebugEnv() {
 	debugMu.Lock()
 	defer debugMu.Unlock()
@@ -67,17 +82,23 @@ func parseDebugEnv() {
 		return
 	}
 
-	// Reset state
-	debugNamespaces = make(map[string]bool)
-	negatedModules = make(map[string]bool)
-	wildcardEnabled = false
-
-	debugValue := os.Getenv("DEBUG")
+	activeSpec = parseSpec(os.Getenv("DEBUG"))
+	specChanged(activeSpec)
+	isInitialized = true
+}
 
-	if debugValue == "" {
-		// No DEBUG env var set
-		isInitialized = true
-		return
+// parseSpec parses a DEBUG value into a namespaceSpec.
+// Format: DEBUG=namespace1,namespace2:*,!namespace3
+// - Use comma to separate multiple namespaces
+// - Use * as wildcard for all namespaces
+// - Prefix with ! to negate a namespace
+// - Use colon (:) for hierarchical namespaces
+// - Append @options to limit a namespace (see Limit)
+func parseSpec(debugValue string) *namespaceSpec {
+	s := &namespaceSpec{
+		raw:             debugValue,
+		debugNamespaces: make(map[string]bool),
+		negatedModules:  make(map[string]bool),
 	}
 
 	// Parse comma-separated namespaces
@@ -88,21 +109,26 @@ func parseDebugEnv() {
 			continue
 		}
 
+		ns, limit := parseLimitEntry(ns)
+		if limit != nil && !strings.HasPrefix(ns, "!") {
+			s.limits = append(s.limits, newLimitRule(ns, *limit))
+		}
+
 		// Support negation with ! prefix
 		if strings.HasPrefix(ns, "!") {
 			trimmedNS := ns[1:]
-			negatedModules[trimmedNS] = true
-			debugNamespaces[trimmedNS] = false
+			s.negatedModules[trimmedNS] = true
+			s.debugNamespaces[trimmedNS] = false
 		} else if ns == "*" {
 			// Global wildcard
-			wildcardEnabled = true
+			s.wildcardEnabled = true
 		} else {
 			// Normal namespace
-			debugNamespaces[ns] = true
+			s.debugNamespaces[ns] = true
 		}
 	}
 
-	isInitialized = true
+	return s
 }
 
 // Debug returns a function that logs debug messages for the specified module.
@@ -112,12 +138,15 @@ func parseDebugEnv() {
 // The debug function will:
 //   - Check if the module is enabled based on the DEBUG environment variable
 //   - Add a timestamp and module prefix to each message
-//   - Output to stderr (for easy redirection)
+//   - Output to stderr (for easy redirection), or wherever SetOutput points
 //
 // Debug messages are printed with the format:
 //
 //	15:04:05.000 module_name message
 //
+// Debug is shorthand for New(module).Printf; use New when you need child
+// namespaces or per-logger output settings.
+//
 // Example:
 //
 //	debug := Debug("app:server")
@@ -127,25 +156,7 @@ func parseDebugEnv() {
 //
 //	12:34:56.789 app:server Server starting on port 8080
 func Debug(module string) func(format string, args ...interface{}) {
-	return func(format string, args ...interface{}) {
-		// We need to ensure we're checking the same condition as IsEnabled
-		debugMu.RLock()
-		enabled := checkEnabled(module)
-		debugMu.RUnlock()
-
-		if !enabled {
-			return
-		}
-
-		// Get timestamp
-		timestamp := time.Now().Format("15:04:05.000")
-
-		// Format message
-		message := fmt.Sprintf(format, args...)
-
-		// Print with timestamp and module name
-		fmt.Fprintf(os.Stderr, "%s %s %s\n", timestamp, module, message)
-	}
+	return New(module).Printf
 }
 
 // IsEnabled checks if debugging is enabled for a module.
@@ -163,6 +174,8 @@ func Debug(module string) func(format string, args ...interface{}) {
 //	    metrics := calculateDetailedMetrics()
 //	    debug("System metrics: %+v", metrics)
 //	}
+//
+// When using a Logger, Logger.Enabled avoids repeating the module string.
 func IsEnabled(module string) bool {
 	debugMu.RLock()
 	defer debugMu.RUnlock()
@@ -172,30 +185,34 @@ func IsEnabled(module string) bool {
 // checkEnabled is the core function to check if a module is enabled
 // This must be called with the lock held
 func checkEnabled(module string) bool {
+	return activeSpec.checkEnabled(module)
+}
+
+// checkEnabled checks if a module is enabled under this spec
+func (s *namespaceSpec) checkEnabled(module string) bool {
 	// First check if module is explicitly negated
-	if isNegated(module) {
+	if s.isNegated(module) {
 		return false
 	}
 
 	// Then check if wildcard is enabled (enabling everything not explicitly negated)
-	if wildcardEnabled {
+	if s.wildcardEnabled {
 		return true
 	}
 
 	// Check if this specific module is directly enabled
-	if debugNamespaces[module] {
+	if s.debugNamespaces[module] {
 		return true
 	}
 
 	// Check for wildcard namespace match
-	return isEnabledByWildcard(module)
+	return s.isEnabledByWildcard(module)
 }
 
 // isNegated checks if a module is explicitly negated
-// This must be called with the lock held
-func isNegated(module string) bool {
+func (s *namespaceSpec) isNegated(module string) bool {
 	// Direct negation
-	if negatedModules[module] {
+	if s.negatedModules[module] {
 		return true
 	}
 
@@ -203,7 +220,7 @@ func isNegated(module string) bool {
 	parts := strings.Split(module, ":")
 	for i := 1; i <= len(parts); i++ {
 		prefix := strings.Join(parts[:i], ":")
-		if negatedModules[prefix] || negatedModules[prefix+"*"] || negatedModules[prefix+":*"] {
+		if s.negatedModules[prefix] || s.negatedModules[prefix+"*"] || s.negatedModules[prefix+":*"] {
 			return true
 		}
 	}
@@ -212,8 +229,7 @@ func isNegated(module string) bool {
 }
 
 // isEnabledByWildcard checks if a module is enabled via wildcard namespace
-// This must be called with the lock held
-func isEnabledByWildcard(module string) bool {
+func (s *namespaceSpec) isEnabledByWildcard(module string) bool {
 	parts := strings.Split(module, ":")
 
 	// Try increasingly specific namespace patterns
@@ -221,12 +237,12 @@ func isEnabledByWildcard(module string) bool {
 		ns := strings.Join(parts[:i], ":")
 
 		// Check for pattern like "app:*" that would enable "app:server"
-		if debugNamespaces[ns+":*"] {
+		if s.debugNamespaces[ns+":*"] {
 			return true
 		}
 
 		// Also check for pattern like "app*" (although less common)
-		if debugNamespaces[ns+"*"] {
+		if s.debugNamespaces[ns+"*"] {
 			return true
 		}
 	}
@@ -239,6 +255,8 @@ func isEnabledByWildcard(module string) bool {
 //
 // You typically call this after changing the DEBUG environment variable with os.Setenv().
 // The new settings will take effect immediately for all subsequent debug calls.
+// DEBUG_FILE and its related variables, and DEBUG_GOROUTINE, are re-read as
+// well.
 //
 // Example:
 //
@@ -253,53 +271,41 @@ func ReloadDebugSettings() {
 	isInitialized = false
 	debugMu.Unlock()
 	parseDebugEnv()
+	configureDebugFile()
+	configureGoroutineFields()
 }
 
-// PrefixWriter is a writer that adds a prefix to each line written.
-// It can also be configured to ignore certain phrases.
-// Implements io.Writer interface for integration with standard libraries.
-//
-// This can be useful for:
-//   - Redirecting standard library log output to include a debug prefix
-//   - Filtering out unwanted messages
-//   - Integrating with libraries that expect an io.Writer
+// Enable replaces the current debug settings with spec, which uses the same
+// syntax as the DEBUG environment variable. Unlike setting DEBUG and calling
+// ReloadDebugSettings, the environment is left untouched. A later call to
+// ReloadDebugSettings reapplies DEBUG.
 //
 // Example:
 //
-//	// Redirect standard library log output
-//	log.SetOutput(&debuggo.PrefixWriter{Prefix: "app:log"})
-//
-//	// Filter out health check logs
-//	logger := &debuggo.PrefixWriter{
-//	    Prefix: "app:api",
-//	    Ignores: []string{"/health", "/ping"},
-//	}
-type PrefixWriter struct {
-	// Prefix is added to the beginning of each line
-	Prefix string
-	// Ignores is a list of phrases that will cause the line to be skipped if found
-	Ignores []string
-}
+//	debuggo.Enable("app:*,!app:metrics")
+func Enable(spec string) {
+	parsed := parseSpec(spec)
 
-// Write implements the io.Writer interface.
-// It adds a prefix to each line and filters out lines containing ignored phrases.
-//
-// The method:
-//   - Checks if the text contains any phrases listed in Ignores
-//   - If not, prepends the Prefix to the text and writes to os.Stderr
-//   - Always returns the original input length to satisfy the io.Writer contract
-func (pw *PrefixWriter) Write(p []byte) (n int, err error) {
-	text := string(p)
-
-	// Check if text contains any ignored phrases
-	if pw.Ignores != nil {
-		for _, ignore := range pw.Ignores {
-			if strings.Contains(text, ignore) {
-				return len(p), nil
-			}
-		}
-	}
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	activeSpec = parsed
+	specChanged(activeSpec)
+	isInitialized = true
+}
 
-	fmt.Fprint(os.Stderr, pw.Prefix+" "+text)
-	return len(p), nil
+// Disable turns off all debug namespaces and returns the previous settings
+// in DEBUG syntax, so they can be restored later with Enable.
+//
+// Example:
+//
+//	prev := debuggo.Disable()
+//	defer debuggo.Enable(prev)
+func Disable() string {
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	prev := activeSpec.raw
+	activeSpec = parseSpec("")
+	specChanged(activeSpec)
+	isInitialized = true
+	return prev
 }
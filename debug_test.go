@@ -3,7 +3,6 @@ package debuggo
 import (
 	"bytes"
 	"os"
-	"strings"
 	"testing"
 	"time"
 )
@@ -123,78 +122,6 @@ func TestNegation(t *testing.T) {
 	}
 }
 
-func TestPrefixWriter(t *testing.T) {
-	// Save original stderr
-	origStderr := os.Stderr
-	defer func() { os.Stderr = origStderr }()
-
-	r, w, _ := os.Pipe()
-	os.Stderr = w
-
-	// Test basic output
-	testPrefix := "TEST-PREFIX"
-	pw := &PrefixWriter{Prefix: testPrefix}
-
-	msg := "Hello, world\n"
-	n, err := pw.Write([]byte(msg))
-
-	if err != nil {
-		t.Errorf("Expected no error, got: %v", err)
-	}
-
-	if n != len(msg) {
-		t.Errorf("Expected to write %d bytes, wrote %d", len(msg), n)
-	}
-
-	w.Close()
-
-	// Read output
-	buf := &bytes.Buffer{}
-	_, _ = buf.ReadFrom(r)
-
-	// Check output
-	expected := testPrefix + " " + msg
-	if buf.String() != expected {
-		t.Errorf("Expected output '%s', got '%s'", expected, buf.String())
-	}
-}
-
-func TestPrefixWriterIgnore(t *testing.T) {
-	// Save original stderr
-	origStderr := os.Stderr
-	defer func() { os.Stderr = origStderr }()
-
-	// Test ignored phrases
-	r, w, _ := os.Pipe()
-	os.Stderr = w
-
-	pw := &PrefixWriter{
-		Prefix:  "TEST",
-		Ignores: []string{"ignore me"},
-	}
-
-	// This should be ignored
-	pw.Write([]byte("This text contains ignore me phrase"))
-
-	// This should be printed
-	pw.Write([]byte("This text should appear"))
-
-	w.Close()
-
-	// Read output
-	buf := &bytes.Buffer{}
-	_, _ = buf.ReadFrom(r)
-
-	// Check output - should only contain the second message
-	if !strings.Contains(buf.String(), "This text should appear") {
-		t.Error("Expected text should appear in output")
-	}
-
-	if strings.Contains(buf.String(), "ignore me") {
-		t.Error("Ignored text should not appear in output")
-	}
-}
-
 func TestReloadDebugSettings(t *testing.T) {
 	// Initial setup
 	os.Setenv("DEBUG", "module1")
@@ -220,3 +147,35 @@ func TestReloadDebugSettings(t *testing.T) {
 		t.Error("module2 should be enabled after reload")
 	}
 }
+
+func TestEnableDisable(t *testing.T) {
+	os.Setenv("DEBUG", "module1")
+	ReloadDebugSettings()
+
+	Enable("app:*,!app:db")
+
+	if !IsEnabled("app:server") || IsEnabled("app:db") || IsEnabled("module1") {
+		t.Error("Enable should replace the settings from DEBUG")
+	}
+	if os.Getenv("DEBUG") != "module1" {
+		t.Error("Enable should not change the environment")
+	}
+
+	prev := Disable()
+	if prev != "app:*,!app:db" {
+		t.Errorf("Expected Disable to return the previous spec, got %q", prev)
+	}
+	if IsEnabled("app:server") {
+		t.Error("Disable should turn off all namespaces")
+	}
+
+	Enable(prev)
+	if !IsEnabled("app:server") || IsEnabled("app:db") {
+		t.Error("Enable should restore the spec returned by Disable")
+	}
+
+	ReloadDebugSettings()
+	if !IsEnabled("module1") || IsEnabled("app:server") {
+		t.Error("ReloadDebugSettings should reapply DEBUG")
+	}
+}
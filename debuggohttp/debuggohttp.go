@@ -0,0 +1,196 @@
+// Package debuggohttp logs HTTP traffic through debuggo namespaces.
+//
+// Middleware logs requests handled by a server, with method, path, status,
+// duration and sizes, and optionally headers and bodies. Transport does the
+// same for requests made by a client, with an optional httptrace timing
+// breakdown. When the namespace is disabled the only cost is a
+// debuggo.IsEnabled check per request.
+//
+// # Basic Usage
+//
+//	handler := debuggohttp.Middleware(debuggohttp.Options{
+//	    Namespace: "app:server:http",
+//	    Headers:   true,
+//	})(mux)
+//
+//	// DEBUG=app:server:http
+//	// 12:34:56.789 app:server:http GET /api/users 200 1.234ms req=0B resp=512B
+//
+//	client := &http.Client{Transport: &debuggohttp.Transport{
+//	    Options: debuggohttp.Options{Namespace: "app:client:http"},
+//	}}
+//
+//	// DEBUG=app:client:http,app:client:http:trace
+//	// 12:34:56.789 app:client:http:trace GET https://api.example.com/users dns=1.2ms connect=10.1ms tls=30.5ms first_byte=82.9ms reused=false
+//	// 12:34:56.789 app:client:http GET https://api.example.com/users 200 84.2ms req=0B
+//
+// Values of sensitive headers such as Authorization are always masked, and
+// bodies pass through the redactions set with debuggo.SetRedactions like any
+// other debug line.
+package debuggohttp
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/GeoffreyPlitt/debuggo"
+)
+
+const (
+	// DefaultServerNamespace is used by Middleware when Options.Namespace
+	// is empty.
+	DefaultServerNamespace = "http:server"
+	// DefaultMaxBodySize is the number of body bytes logged when
+	// Options.MaxBodySize is zero.
+	DefaultMaxBodySize = 1 << 10
+)
+
+// DefaultRedactHeaders lists the headers whose values are always masked.
+var DefaultRedactHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+}
+
+// Options configures what Middleware and Transport log for each request.
+type Options struct {
+	// Namespace is the debuggo namespace lines are logged under
+	Namespace string
+	// Headers logs request and response headers, one per line
+	Headers bool
+	// Bodies logs request and response bodies, up to MaxBodySize bytes each
+	Bodies bool
+	// MaxBodySize is the number of body bytes logged.
+	// Defaults to DefaultMaxBodySize.
+	MaxBodySize int
+	// RedactHeaders lists headers to mask in addition to DefaultRedactHeaders
+	RedactHeaders []string
+}
+
+// logger returns the logger for the options' namespace.
+func (opts Options) logger(defaultNamespace string) *debuggo.Logger {
+	if opts.Namespace == "" {
+		return debuggo.New(defaultNamespace)
+	}
+	return debuggo.New(opts.Namespace)
+}
+
+// bodyCapture returns a capture for a body, keeping its bytes only when
+// bodies are logged.
+func (opts Options) bodyCapture() *capture {
+	c := &capture{}
+	if opts.Bodies {
+		c.limit = opts.MaxBodySize
+		if c.limit <= 0 {
+			c.limit = DefaultMaxBodySize
+		}
+	}
+	return c
+}
+
+// logHeaders logs each header on its own line, sorted by name, marked with
+// > for requests and < for responses like curl -v does.
+func (opts Options) logHeaders(ctx context.Context, logger *debuggo.Logger, marker string, h http.Header) {
+	if !opts.Headers {
+		return
+	}
+
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range h[name] {
+			if opts.redacted(name) {
+				value = debuggo.RedactedText
+			}
+			logger.PrintfContext(ctx, "%s %s: %s", marker, name, value)
+		}
+	}
+}
+
+// logBody logs the captured start of a body.
+func (opts Options) logBody(ctx context.Context, logger *debuggo.Logger, marker string, c *capture) {
+	if !opts.Bodies || c.size() == 0 {
+		return
+	}
+	logger.PrintfContext(ctx, "%s body: %s", marker, c)
+}
+
+// redacted reports whether the value of header name must be masked.
+func (opts Options) redacted(name string) bool {
+	for _, list := range [][]string{DefaultRedactHeaders, opts.RedactHeaders} {
+		for _, h := range list {
+			if strings.EqualFold(h, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// capture counts the bytes of a body and keeps up to limit of them. It is
+// safe for concurrent use, since a transport may still be sending a request
+// body after the response has arrived.
+type capture struct {
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	limit int
+	n     int64
+}
+
+// record notes that p passed through the body.
+func (c *capture) record(p []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.n += int64(len(p))
+	if room := c.limit - c.buf.Len(); room > 0 {
+		c.buf.Write(p[:min(room, len(p))])
+	}
+}
+
+// String returns the kept bytes, quoted if they are not valid UTF-8, and
+// notes how many bytes were left out.
+func (c *capture) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := c.buf.String()
+	if !utf8.ValidString(s) {
+		s = strconv.Quote(s)
+	}
+	if more := c.n - int64(c.buf.Len()); more > 0 {
+		s += " ... (" + strconv.FormatInt(more, 10) + " more bytes)"
+	}
+	return s
+}
+
+// count notes that n bytes passed through the body without being seen.
+func (c *capture) count(n int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.n += n
+}
+
+// keeping reports whether the capture keeps any of the bytes it sees.
+func (c *capture) keeping() bool {
+	return c.limit > 0
+}
+
+// size returns the number of bytes seen so far.
+func (c *capture) size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.n
+}
@@ -0,0 +1,208 @@
+package debuggohttp
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Middleware returns middleware that logs every request passing through it
+// under opts.Namespace (DefaultServerNamespace if empty), once the wrapped
+// handler has finished:
+//
+//	GET /api/users?page=2 200 1.234ms req=0B resp=512B
+//
+// Requests whose connection the handler hijacks, such as WebSocket upgrades,
+// are logged with "hijacked" in place of the status.
+//
+// Lines are logged with the request's context, so context fields and hooks
+// such as debuggootel apply. When the namespace is disabled requests are
+// passed straight through.
+//
+// Example:
+//
+//	mux := http.NewServeMux()
+//	http.ListenAndServe(":8080", debuggohttp.Middleware(debuggohttp.Options{
+//	    Namespace: "app:server:http",
+//	    Bodies:    true,
+//	})(mux))
+func Middleware(opts Options) func(http.Handler) http.Handler {
+	logger := opts.logger(DefaultServerNamespace)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !logger.Enabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+
+			reqBody := opts.bodyCapture()
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = &recordingBody{ReadCloser: r.Body, capture: reqBody}
+			}
+			rw := &responseRecorder{ResponseWriter: w, status: http.StatusOK, body: opts.bodyCapture()}
+
+			next.ServeHTTP(rw.wrap(), r)
+
+			// A hijacked connection has no status the handler wrote through w
+			status := strconv.Itoa(rw.status)
+			if rw.hijacked {
+				status = "hijacked"
+			}
+
+			ctx := r.Context()
+			logger.PrintfContext(ctx, "%s %s %s %s req=%dB resp=%dB",
+				r.Method, r.URL.RequestURI(), status, time.Since(start), reqBody.size(), rw.body.size())
+			opts.logHeaders(ctx, logger, ">", r.Header)
+			opts.logBody(ctx, logger, ">", reqBody)
+			opts.logHeaders(ctx, logger, "<", rw.Header())
+			opts.logBody(ctx, logger, "<", rw.body)
+		})
+	}
+}
+
+// recordingBody captures a request body as the handler reads it.
+type recordingBody struct {
+	io.ReadCloser
+	capture *capture
+}
+
+// Read implements the io.Reader interface.
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.capture.record(p[:n])
+	return n, err
+}
+
+// responseRecorder captures the status and body written by a handler.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	hijacked    bool
+	body        *capture
+}
+
+// WriteHeader implements the http.ResponseWriter interface.
+func (rw *responseRecorder) WriteHeader(status int) {
+	// Informational 1xx responses may precede the final status
+	if !rw.wroteHeader && status >= 200 {
+		rw.status = status
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements the http.ResponseWriter interface.
+func (rw *responseRecorder) Write(p []byte) (int, error) {
+	rw.wroteHeader = true
+	n, err := rw.ResponseWriter.Write(p)
+	rw.body.record(p[:n])
+	return n, err
+}
+
+// ReadFrom implements the io.ReaderFrom interface. When bodies are not kept
+// it hands src to the underlying writer, which may use sendfile.
+func (rw *responseRecorder) ReadFrom(src io.Reader) (int64, error) {
+	if rf, ok := rw.ResponseWriter.(io.ReaderFrom); ok && !rw.body.keeping() {
+		rw.wroteHeader = true
+		n, err := rf.ReadFrom(src)
+		rw.body.count(n)
+		return n, err
+	}
+	// Hide ReadFrom from io.Copy, which would otherwise call it again
+	return io.Copy(struct{ io.Writer }{rw}, src)
+}
+
+// Unwrap returns the underlying writer, for use by http.ResponseController.
+func (rw *responseRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// wrap returns rw with the optional http.Flusher, http.Hijacker and
+// http.Pusher interfaces of the underlying writer, and only those, so that
+// handlers checking for them behave the same whether or not the namespace
+// is enabled.
+func (rw *responseRecorder) wrap() http.ResponseWriter {
+	_, f := rw.ResponseWriter.(http.Flusher)
+	_, h := rw.ResponseWriter.(http.Hijacker)
+	_, p := rw.ResponseWriter.(http.Pusher)
+
+	switch {
+	case f && h && p:
+		return struct {
+			*responseRecorder
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{rw, flusher{rw}, hijacker{rw}, pusher{rw}}
+	case f && h:
+		return struct {
+			*responseRecorder
+			http.Flusher
+			http.Hijacker
+		}{rw, flusher{rw}, hijacker{rw}}
+	case f && p:
+		return struct {
+			*responseRecorder
+			http.Flusher
+			http.Pusher
+		}{rw, flusher{rw}, pusher{rw}}
+	case h && p:
+		return struct {
+			*responseRecorder
+			http.Hijacker
+			http.Pusher
+		}{rw, hijacker{rw}, pusher{rw}}
+	case f:
+		return struct {
+			*responseRecorder
+			http.Flusher
+		}{rw, flusher{rw}}
+	case h:
+		return struct {
+			*responseRecorder
+			http.Hijacker
+		}{rw, hijacker{rw}}
+	case p:
+		return struct {
+			*responseRecorder
+			http.Pusher
+		}{rw, pusher{rw}}
+	}
+	return rw
+}
+
+// flusher forwards http.Flusher to the underlying writer.
+type flusher struct{ rw *responseRecorder }
+
+// Flush implements the http.Flusher interface.
+func (f flusher) Flush() {
+	f.rw.wroteHeader = true
+	f.rw.ResponseWriter.(http.Flusher).Flush()
+}
+
+// hijacker forwards http.Hijacker to the underlying writer.
+type hijacker struct{ rw *responseRecorder }
+
+// Hijack implements the http.Hijacker interface.
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buf, err := h.rw.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil {
+		h.rw.hijacked = true
+	}
+	return conn, buf, err
+}
+
+// pusher forwards http.Pusher to the underlying writer.
+type pusher struct{ rw *responseRecorder }
+
+// Push implements the http.Pusher interface.
+func (p pusher) Push(target string, opts *http.PushOptions) error {
+	return p.rw.ResponseWriter.(http.Pusher).Push(target, opts)
+}
@@ -0,0 +1,307 @@
+package debuggohttp
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/GeoffreyPlitt/debuggo"
+	"github.com/GeoffreyPlitt/debuggo/debuggotest"
+)
+
+// echo responds with the request body, upper-cased.
+var echo = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set("Set-Cookie", "session=secret")
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(strings.ToUpper(string(body))))
+})
+
+func TestMiddleware(t *testing.T) {
+	testCases := []struct {
+		description string
+		opts        Options
+		expected    []string
+	}{
+		{
+			description: "summary only",
+			opts:        Options{Namespace: "app:http"},
+			expected:    []string{`^POST /echo\?x=1 201 \S+ req=5B resp=5B$`},
+		},
+		{
+			description: "headers",
+			opts:        Options{Namespace: "app:http", Headers: true, RedactHeaders: []string{"X-Session"}},
+			expected: []string{
+				`^POST /echo\?x=1 201 `,
+				`^> Authorization: \[REDACTED\]$`,
+				`^> X-Session: \[REDACTED\]$`,
+				`^> X-Trace: abc$`,
+				`^< Content-Type: text/plain$`,
+				`^< Set-Cookie: \[REDACTED\]$`,
+			},
+		},
+		{
+			description: "bodies are truncated",
+			opts:        Options{Namespace: "app:http", Bodies: true, MaxBodySize: 3},
+			expected: []string{
+				`^POST /echo\?x=1 201 `,
+				`^> body: hel \.\.\. \(2 more bytes\)$`,
+				`^< body: HEL \.\.\. \(2 more bytes\)$`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			buf := debuggotest.Capture(t, "app:http")
+
+			req := httptest.NewRequest("POST", "/echo?x=1", strings.NewReader("hello"))
+			req.Header.Set("Authorization", "Bearer token")
+			req.Header.Set("X-Session", "s3cret")
+			req.Header.Set("X-Trace", "abc")
+			rec := httptest.NewRecorder()
+
+			Middleware(tc.opts)(echo).ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusCreated || rec.Body.String() != "HELLO" {
+				t.Errorf("Expected the handler's response to pass through, got %d %q", rec.Code, rec.Body.String())
+			}
+
+			messages := buf.Messages("app:http")
+			if len(messages) != len(tc.expected) {
+				t.Fatalf("Expected %d lines, got %q", len(tc.expected), messages)
+			}
+			for i, pattern := range tc.expected {
+				if !regexp.MustCompile(pattern).MatchString(messages[i]) {
+					t.Errorf("Expected line %d to match %q, got %q", i, pattern, messages[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMiddlewareDefaults(t *testing.T) {
+	buf := debuggotest.Capture(t, DefaultServerNamespace)
+
+	handler := Middleware(Options{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	debuggotest.AssertLogged(t, DefaultServerNamespace, "GET / 200")
+	if strings.Contains(buf.String(), "body") {
+		t.Errorf("Expected bodies to be off by default, got %q", buf.String())
+	}
+}
+
+func TestMiddlewareDisabled(t *testing.T) {
+	buf := debuggotest.Capture(t, "app:other")
+
+	var sawBody io.ReadCloser
+	var sawWriter http.ResponseWriter
+	handler := Middleware(Options{Namespace: "app:http"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sawBody, sawWriter = r.Body, w
+	}))
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader("x"))
+	body := req.Body
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if sawBody != body || sawWriter != http.ResponseWriter(rec) {
+		t.Error("Expected the request to pass through unwrapped when disabled")
+	}
+	if len(buf.Records()) != 0 {
+		t.Errorf("Expected no output, got %q", buf.String())
+	}
+}
+
+func TestMiddlewareRedactsBodies(t *testing.T) {
+	debuggotest.Capture(t, "app:http")
+
+	prev := debuggo.SetRedactions(debuggo.RedactEmails)
+	defer debuggo.SetRedactions(prev...)
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader("jane@example.com"))
+	Middleware(Options{Namespace: "app:http", Bodies: true})(echo).ServeHTTP(httptest.NewRecorder(), req)
+
+	debuggotest.AssertLogged(t, "app:http", "> body: [REDACTED]")
+	debuggotest.AssertNotLogged(t, "app:http", "example.com")
+}
+
+func TestMiddlewareWithServer(t *testing.T) {
+	debuggotest.Capture(t, "app:http")
+
+	srv := httptest.NewServer(Middleware(Options{Namespace: "app:http"})(http.NotFoundHandler()))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	debuggotest.AssertLogged(t, "app:http", "GET /missing 404")
+}
+
+func TestMiddlewareUpgrade(t *testing.T) {
+	buf := debuggotest.Capture(t, "app:http")
+
+	// upgrade switches to a protocol that echoes each line back
+	upgrade := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			http.Error(w, "hijacking not supported", http.StatusInternalServerError)
+			return
+		}
+		conn, rw, err := hj.Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+		rw.Flush()
+		line, _ := rw.ReadString('\n')
+		rw.WriteString(line)
+		rw.Flush()
+	})
+
+	// logged is closed once the middleware has logged the request, which
+	// happens after the client has seen the echo
+	logged := make(chan struct{})
+	handler := Middleware(Options{Namespace: "app:http"})(upgrade)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(logged)
+		handler.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	req, _ := http.NewRequest("GET", srv.URL+"/echo", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "echo")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected the upgrade to succeed, got %s", resp.Status)
+	}
+	conn := resp.Body.(io.ReadWriter)
+	io.WriteString(conn, "ping\n")
+	line, _ := bufio.NewReader(conn).ReadString('\n')
+	if line != "ping\n" {
+		t.Errorf("Expected the upgraded connection to echo, got %q", line)
+	}
+
+	<-logged
+	if !buf.Contains("app:http", "GET /echo hijacked ") {
+		t.Errorf("Expected the request to be logged as hijacked, got %q", buf.Messages("app:http"))
+	}
+}
+
+// plainWriter is a ResponseWriter with none of the optional interfaces.
+type plainWriter struct {
+	http.ResponseWriter
+}
+
+// allWriter adds every optional interface to a ResponseWriter.
+type allWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (allWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, http.ErrNotSupported
+}
+
+func (allWriter) Push(string, *http.PushOptions) error {
+	return http.ErrNotSupported
+}
+
+func TestMiddlewareOptionalInterfaces(t *testing.T) {
+	testCases := []struct {
+		description string
+		writer      http.ResponseWriter
+	}{
+		{description: "none", writer: plainWriter{httptest.NewRecorder()}},
+		{description: "flusher", writer: httptest.NewRecorder()},
+		{description: "all", writer: allWriter{httptest.NewRecorder()}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			debuggotest.Capture(t, "app:http")
+
+			var w http.ResponseWriter
+			handler := Middleware(Options{Namespace: "app:http"})(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				w = rw
+			}))
+			handler.ServeHTTP(tc.writer, httptest.NewRequest("GET", "/", nil))
+
+			_, wantFlusher := tc.writer.(http.Flusher)
+			_, wantHijacker := tc.writer.(http.Hijacker)
+			_, wantPusher := tc.writer.(http.Pusher)
+			if _, ok := w.(http.Flusher); ok != wantFlusher {
+				t.Errorf("Expected http.Flusher to be %v, got %v", wantFlusher, ok)
+			}
+			if _, ok := w.(http.Hijacker); ok != wantHijacker {
+				t.Errorf("Expected http.Hijacker to be %v, got %v", wantHijacker, ok)
+			}
+			if _, ok := w.(http.Pusher); ok != wantPusher {
+				t.Errorf("Expected http.Pusher to be %v, got %v", wantPusher, ok)
+			}
+		})
+	}
+}
+
+func TestMiddlewareReadFrom(t *testing.T) {
+	testCases := []struct {
+		description string
+		opts        Options
+		expected    []string
+	}{
+		{
+			description: "passed to the underlying writer",
+			opts:        Options{Namespace: "app:http"},
+			expected:    []string{"GET / 200 ", " resp=11B"},
+		},
+		{
+			description: "recorded when bodies are logged",
+			opts:        Options{Namespace: "app:http", Bodies: true},
+			expected:    []string{" resp=11B", "< body: hello world"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			debuggotest.Capture(t, "app:http")
+
+			srv := httptest.NewServer(Middleware(tc.opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.Copy(w, strings.NewReader("hello world"))
+			})))
+			defer srv.Close()
+
+			resp, err := http.Get(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if string(body) != "hello world" {
+				t.Errorf("Expected the body to pass through, got %q", body)
+			}
+			for _, want := range tc.expected {
+				debuggotest.AssertLogged(t, "app:http", want)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+module github.com/GeoffreyPlitt/debuggo/debuggootel
+
+go 1.22.0
+
+require (
+	github.com/GeoffreyPlitt/debuggo v0.0.0-00000000000000-000000000000
+	go.opentelemetry.io/otel v1.35.0
+	go.opentelemetry.io/otel/sdk v1.35.0
+	go.opentelemetry.io/otel/trace v1.35.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	go.opentelemetry.io/auto/sdk v1.1.0 // indirect
+	go.opentelemetry.io/otel/metric v1.35.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+)
+
+// The root module has no release with AddContextHook and Record.Fields yet,
+// so this module builds against the working tree. To release it:
+//
+//  1. Tag the root module (e.g. v0.2.0).
+//  2. Require that tag above, drop this replace and run go mod tidy.
+//  3. Tag this module as debuggootel/v0.2.0.
+//
+// Until then it cannot be fetched with go get.
+replace github.com/GeoffreyPlitt/debuggo => ../
@@ -0,0 +1,79 @@
+// Package debuggootel correlates debuggo output with OpenTelemetry traces.
+//
+// Once installed, every line logged through a context-aware debuggo function
+// (debuggo.DebugContext or Logger.PrintfContext) inside a traced request gets
+// trace_id and span_id fields, and can optionally be recorded as an event on
+// the active span.
+//
+// This package lives in its own module so that the core debuggo package stays
+// free of third-party dependencies.
+//
+// # Basic Usage
+//
+//	remove := debuggootel.Install(debuggootel.Options{SpanEvents: true})
+//	defer remove()
+//
+//	var debug = debuggo.DebugContext("app:server:http")
+//
+//	ctx, span := tracer.Start(ctx, "handle")
+//	debug(ctx, "Handling %s", path)
+//	// 12:34:56.789 app:server:http Handling /api/users trace_id=4bf9... span_id=00f0...
+package debuggootel
+
+import (
+	"context"
+
+	"github.com/GeoffreyPlitt/debuggo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultEventName is the span event name used when Options.EventName is empty.
+const DefaultEventName = "debug"
+
+// Options configures the hook installed by Install.
+type Options struct {
+	// SpanEvents records each debug line as an event on the active span,
+	// with the namespace and message as attributes
+	SpanEvents bool
+	// EventName is the name of recorded span events. Defaults to DefaultEventName.
+	EventName string
+}
+
+// Install registers a debuggo context hook that adds trace_id and span_id
+// fields for lines logged within a valid span context, and returns a function
+// that uninstalls it again.
+func Install(opts Options) (remove func()) {
+	return debuggo.AddContextHook(opts.hook)
+}
+
+// hook is the debuggo.ContextHook installed by Install.
+func (opts Options) hook(ctx context.Context, r *debuggo.Record) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+
+	r.Fields = append(r.Fields,
+		debuggo.F("trace_id", sc.TraceID().String()),
+		debuggo.F("span_id", sc.SpanID().String()),
+	)
+
+	if !opts.SpanEvents {
+		return
+	}
+
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	name := opts.EventName
+	if name == "" {
+		name = DefaultEventName
+	}
+	span.AddEvent(name, trace.WithTimestamp(r.Time), trace.WithAttributes(
+		attribute.String("debug.namespace", r.Namespace),
+		attribute.String("debug.message", r.Message),
+	))
+}
@@ -0,0 +1,115 @@
+package debuggootel
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/GeoffreyPlitt/debuggo"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newTracer returns a tracer whose finished spans are kept in memory.
+func newTracer() (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	return tp, exporter
+}
+
+func TestInstallAddsTraceFields(t *testing.T) {
+	os.Setenv("DEBUG", "app:*")
+	debuggo.ReloadDebugSettings()
+
+	remove := Install(Options{})
+	defer remove()
+
+	tp, _ := newTracer()
+	ctx, span := tp.Tracer("test").Start(context.Background(), "handle")
+	defer span.End()
+
+	buf := &bytes.Buffer{}
+	debuggo.New("app:http").WithOutput(buf).PrintfContext(ctx, "Handling %s", "/api/users")
+
+	sc := span.SpanContext()
+	expected := " app:http Handling /api/users trace_id=" + sc.TraceID().String() +
+		" span_id=" + sc.SpanID().String() + "\n"
+	if !strings.HasSuffix(buf.String(), expected) {
+		t.Errorf("Expected output ending in %q, got %q", expected, buf.String())
+	}
+}
+
+func TestInstallWithoutSpan(t *testing.T) {
+	os.Setenv("DEBUG", "app:*")
+	debuggo.ReloadDebugSettings()
+
+	remove := Install(Options{SpanEvents: true})
+	defer remove()
+
+	buf := &bytes.Buffer{}
+	debuggo.New("app:http").WithOutput(buf).PrintfContext(context.Background(), "untraced")
+
+	if strings.Contains(buf.String(), "trace_id") {
+		t.Errorf("Expected no trace fields outside a span, got %q", buf.String())
+	}
+}
+
+func TestSpanEvents(t *testing.T) {
+	os.Setenv("DEBUG", "app:*")
+	debuggo.ReloadDebugSettings()
+
+	testCases := []struct {
+		opts          Options
+		expectedEvent string
+		description   string
+	}{
+		{Options{}, "", "Events are off by default"},
+		{Options{SpanEvents: true}, DefaultEventName, "Default event name"},
+		{Options{SpanEvents: true, EventName: "log"}, "log", "Custom event name"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			remove := Install(tc.opts)
+			defer remove()
+
+			tp, exporter := newTracer()
+			ctx, span := tp.Tracer("test").Start(context.Background(), "handle")
+
+			logger := debuggo.New("app:db").WithOutput(&bytes.Buffer{})
+			logger.PrintfContext(ctx, "Query took %dms", 25)
+			debuggo.New("other").PrintfContext(ctx, "Disabled namespaces add no events")
+			span.End()
+
+			spans := exporter.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("Expected 1 span, got %d", len(spans))
+			}
+			events := spans[0].Events
+
+			if tc.expectedEvent == "" {
+				if len(events) != 0 {
+					t.Errorf("Expected no events, got %v", events)
+				}
+				return
+			}
+
+			if len(events) != 1 {
+				t.Fatalf("Expected 1 event, got %v", events)
+			}
+			if events[0].Name != tc.expectedEvent {
+				t.Errorf("Expected event name %s, got %s", tc.expectedEvent, events[0].Name)
+			}
+
+			attrs := map[string]string{}
+			for _, kv := range events[0].Attributes {
+				attrs[string(kv.Key)] = kv.Value.AsString()
+			}
+			if attrs["debug.namespace"] != "app:db" || attrs["debug.message"] != "Query took 25ms" {
+				t.Errorf("Unexpected event attributes %v", attrs)
+			}
+		})
+	}
+}
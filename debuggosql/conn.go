@@ -0,0 +1,203 @@
+package debuggosql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"time"
+)
+
+// conn wraps a driver.Conn. It implements every optional connection
+// interface, falling back the way database/sql would when the wrapped
+// connection does not.
+type conn struct {
+	driver.Conn
+	log *queryLog
+}
+
+// Prepare implements the driver.Conn interface.
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext implements the driver.ConnPrepareContext interface.
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	start := time.Now()
+	var s driver.Stmt
+	var err error
+	if pc, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		s, err = pc.PrepareContext(ctx, query)
+	} else {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		s, err = c.Conn.Prepare(query)
+	}
+
+	if err != nil {
+		// Successful prepares are logged when the statement runs
+		if c.log.enabled() {
+			c.log.done(ctx, "prepare", query, nil, start, nil, err)
+		}
+		return nil, err
+	}
+	return &stmt{Stmt: s, conn: c, query: query, log: c.log}, nil
+}
+
+// Begin implements the driver.Conn interface.
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx implements the driver.ConnBeginTx interface.
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if !c.log.enabled() {
+		return c.beginTx(ctx, opts)
+	}
+
+	start := time.Now()
+	tx, err := c.beginTx(ctx, opts)
+	c.log.done(ctx, "begin", "", nil, start, nil, err)
+	if err != nil {
+		return nil, err
+	}
+	return &wrappedTx{Tx: tx, ctx: ctx, log: c.log}, nil
+}
+
+func (c *conn) beginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if bt, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return bt.BeginTx(ctx, opts)
+	}
+
+	// Mirror database/sql's checks for drivers without BeginTx
+	if opts.Isolation != 0 {
+		return nil, errors.New("debuggosql: driver does not support non-default isolation level")
+	}
+	if opts.ReadOnly {
+		return nil, errors.New("debuggosql: driver does not support read-only transactions")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.Conn.Begin()
+}
+
+// ExecContext implements the driver.ExecerContext interface.
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if !c.log.enabled() {
+		return c.execContext(ctx, query, args)
+	}
+
+	start := time.Now()
+	result, err := c.execContext(ctx, query, args)
+	c.log.done(ctx, "exec", query, args, start, result, err)
+	return result, err
+}
+
+func (c *conn) execContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if ec, ok := c.Conn.(driver.ExecerContext); ok {
+		return ec.ExecContext(ctx, query, args)
+	}
+	if e, ok := c.Conn.(driver.Execer); ok {
+		vals, err := values(args)
+		if err != nil {
+			return nil, err
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return e.Exec(query, vals)
+	}
+	// database/sql prepares the statement instead
+	return nil, driver.ErrSkip
+}
+
+// QueryContext implements the driver.QueryerContext interface.
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if !c.log.enabled() {
+		return c.queryContext(ctx, query, args)
+	}
+
+	start := time.Now()
+	rows, err := c.queryContext(ctx, query, args)
+	c.log.done(ctx, "query", query, args, start, nil, err)
+	return rows, err
+}
+
+func (c *conn) queryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if qc, ok := c.Conn.(driver.QueryerContext); ok {
+		return qc.QueryContext(ctx, query, args)
+	}
+	if q, ok := c.Conn.(driver.Queryer); ok {
+		vals, err := values(args)
+		if err != nil {
+			return nil, err
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return q.Query(query, vals)
+	}
+	// database/sql prepares the statement instead
+	return nil, driver.ErrSkip
+}
+
+// Ping implements the driver.Pinger interface.
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// ResetSession implements the driver.SessionResetter interface.
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid implements the driver.Validator interface.
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// CheckNamedValue implements the driver.NamedValueChecker interface.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nc, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return nc.CheckNamedValue(nv)
+	}
+	// Use database/sql's default conversion
+	return driver.ErrSkip
+}
+
+// wrappedTx logs the end of a transaction.
+type wrappedTx struct {
+	driver.Tx
+	ctx context.Context
+	log *queryLog
+}
+
+// Commit implements the driver.Tx interface.
+func (tx *wrappedTx) Commit() error {
+	start := time.Now()
+	err := tx.Tx.Commit()
+	if tx.log.enabled() {
+		tx.log.done(tx.ctx, "commit", "", nil, start, nil, err)
+	}
+	return err
+}
+
+// Rollback implements the driver.Tx interface.
+func (tx *wrappedTx) Rollback() error {
+	start := time.Now()
+	err := tx.Tx.Rollback()
+	if tx.log.enabled() {
+		tx.log.done(tx.ctx, "rollback", "", nil, start, nil, err)
+	}
+	return err
+}
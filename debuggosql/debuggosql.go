@@ -0,0 +1,262 @@
+// Package debuggosql logs database/sql queries through a debuggo namespace.
+//
+// It wraps a database/sql/driver.Driver or Connector so that every query,
+// exec and transaction is logged with its arguments, duration, rows affected
+// and error. When the namespace is disabled the only cost is a
+// debuggo.IsEnabled check per call.
+//
+// # Basic Usage
+//
+//	sql.Register("postgres-debug", debuggosql.Wrap(&pq.Driver{}, debuggosql.Options{
+//	    Namespace: "app:database",
+//	}))
+//	db, err := sql.Open("postgres-debug", dsn)
+//
+//	// DEBUG=app:database
+//	// 12:34:56.789 app:database query SELECT name FROM users WHERE id = $1 [42] 1.234ms
+//	// 12:34:56.790 app:database exec UPDATE users SET seen = now() WHERE id = $1 [42] 2.5ms rows_affected=1
+//
+// Arguments can be hidden with Options.RedactArgs, and query text passes
+// through the redactions set with debuggo.SetRedactions like any other debug
+// line.
+package debuggosql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/GeoffreyPlitt/debuggo"
+)
+
+// DefaultNamespace is used when Options.Namespace is empty.
+const DefaultNamespace = "sql"
+
+// maxArgLength is the longest string or byte argument logged in full.
+const maxArgLength = 64
+
+// Options configures what is logged for each database call.
+type Options struct {
+	// Namespace is the debuggo namespace lines are logged under
+	Namespace string
+	// RedactArgs logs every query argument as debuggo.RedactedText
+	RedactArgs bool
+}
+
+// Wrap returns a driver that logs the calls made through d. Register it
+// with sql.Register under a new name to use it with sql.Open.
+func Wrap(d driver.Driver, opts Options) driver.Driver {
+	return &wrappedDriver{Driver: d, log: opts.newLog()}
+}
+
+// WrapConnector returns a connector that logs the calls made through c, for
+// use with sql.OpenDB.
+//
+// Example:
+//
+//	db := sql.OpenDB(debuggosql.WrapConnector(connector, debuggosql.Options{
+//	    Namespace: "app:database",
+//	}))
+func WrapConnector(c driver.Connector, opts Options) driver.Connector {
+	log := opts.newLog()
+	return &wrappedConnector{
+		Connector: c,
+		driver:    &wrappedDriver{Driver: c.Driver(), log: log},
+		log:       log,
+	}
+}
+
+// wrappedDriver implements driver.Driver and driver.DriverContext.
+type wrappedDriver struct {
+	driver.Driver
+	log *queryLog
+}
+
+// Open implements the driver.Driver interface.
+func (d *wrappedDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: c, log: d.log}, nil
+}
+
+// OpenConnector implements the driver.DriverContext interface.
+func (d *wrappedDriver) OpenConnector(name string) (driver.Connector, error) {
+	if dc, ok := d.Driver.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return &wrappedConnector{Connector: c, driver: d, log: d.log}, nil
+	}
+	return &dsnConnector{name: name, driver: d}, nil
+}
+
+// wrappedConnector implements driver.Connector.
+type wrappedConnector struct {
+	driver.Connector
+	driver *wrappedDriver
+	log    *queryLog
+}
+
+// Connect implements the driver.Connector interface.
+func (c *wrappedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	dc, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: dc, log: c.log}, nil
+}
+
+// Driver implements the driver.Connector interface.
+func (c *wrappedConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// Close implements the io.Closer interface, which sql.DB.Close calls on its
+// connector, by closing the wrapped connector if it has a Close method.
+func (c *wrappedConnector) Close() error {
+	if closer, ok := c.Connector.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// dsnConnector is the connector for drivers without driver.DriverContext.
+type dsnConnector struct {
+	name   string
+	driver *wrappedDriver
+}
+
+// Connect implements the driver.Connector interface.
+func (c *dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.name)
+}
+
+// Driver implements the driver.Connector interface.
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// queryLog writes the debug lines for one wrapped driver.
+type queryLog struct {
+	logger     *debuggo.Logger
+	redactArgs bool
+}
+
+func (opts Options) newLog() *queryLog {
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	return &queryLog{logger: debuggo.New(namespace), redactArgs: opts.RedactArgs}
+}
+
+// enabled reports whether calls should be timed and logged.
+func (l *queryLog) enabled() bool {
+	return l.logger.Enabled()
+}
+
+// done logs a finished call, with the query's whitespace collapsed onto one
+// line. Calls that returned driver.ErrSkip are not logged, as database/sql
+// retries them another way.
+func (l *queryLog) done(ctx context.Context, op, query string, args []driver.NamedValue, start time.Time, result driver.Result, err error) {
+	if errors.Is(err, driver.ErrSkip) {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(op)
+	if query != "" {
+		b.WriteByte(' ')
+		b.WriteString(strings.Join(strings.Fields(query), " "))
+	}
+	if len(args) > 0 {
+		b.WriteByte(' ')
+		b.WriteString(l.formatArgs(args))
+	}
+	b.WriteByte(' ')
+	b.WriteString(time.Since(start).String())
+
+	if err != nil {
+		fmt.Fprintf(&b, " error: %v", err)
+	} else if result != nil {
+		if n, err := result.RowsAffected(); err == nil {
+			fmt.Fprintf(&b, " rows_affected=%d", n)
+		}
+	}
+
+	l.logger.PrintfContext(ctx, "%s", b.String())
+}
+
+// formatArgs renders query arguments as [1 "two" :name=3], shortening long
+// strings and byte slices.
+func (l *queryLog) formatArgs(args []driver.NamedValue) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, arg := range args {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		if arg.Name != "" {
+			b.WriteString(":" + arg.Name + "=")
+		}
+		if l.redactArgs {
+			b.WriteString(debuggo.RedactedText)
+			continue
+		}
+		b.WriteString(formatArg(arg.Value))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// formatArg renders a single argument value.
+func formatArg(v driver.Value) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		if len(v) > maxArgLength {
+			return fmt.Sprintf("%q... (%d bytes)", v[:maxArgLength], len(v))
+		}
+		return fmt.Sprintf("%q", v)
+	case []byte:
+		if len(v) > maxArgLength || !utf8.Valid(v) {
+			return fmt.Sprintf("<%d bytes>", len(v))
+		}
+		return fmt.Sprintf("%q", v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// namedValues converts legacy driver values to named values for logging.
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+// values converts named values for drivers without context support, which
+// cannot take named parameters.
+func values(args []driver.NamedValue) ([]driver.Value, error) {
+	vals := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("debuggosql: driver does not support the use of Named Parameters")
+		}
+		vals[i] = arg.Value
+	}
+	return vals, nil
+}
@@ -0,0 +1,182 @@
+package debuggosql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/GeoffreyPlitt/debuggo/debuggotest"
+)
+
+// driverCount keeps registered driver names unique.
+var driverCount atomic.Int64
+
+// openDB registers a wrapped fake driver and opens a database with it.
+func openDB(t *testing.T, d *fakeDriver, opts Options) *sql.DB {
+	t.Helper()
+
+	name := fmt.Sprintf("debuggosql-fake-%d", driverCount.Add(1))
+	sql.Register(name, Wrap(d, opts))
+
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// assertLines checks that messages match patterns one to one.
+func assertLines(t *testing.T, messages, patterns []string) {
+	t.Helper()
+
+	if len(messages) != len(patterns) {
+		t.Fatalf("Expected %d lines, got %q", len(patterns), messages)
+	}
+	for i, pattern := range patterns {
+		if !regexp.MustCompile(pattern).MatchString(messages[i]) {
+			t.Errorf("Expected line %d to match %q, got %q", i, pattern, messages[i])
+		}
+	}
+}
+
+func TestQueries(t *testing.T) {
+	testCases := []struct {
+		description string
+		legacy      bool
+	}{
+		{"driver with context support", false},
+		{"legacy driver", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			buf := debuggotest.Capture(t, "app:database")
+			db := openDB(t, &fakeDriver{legacy: tc.legacy}, Options{Namespace: "app:database"})
+
+			if _, err := db.Exec("INSERT INTO users (name)\n\tVALUES (?)", "jane"); err != nil {
+				t.Fatal(err)
+			}
+
+			var name string
+			if err := db.QueryRow("SELECT name FROM users").Scan(&name); err != nil || name != "jane" {
+				t.Fatalf("Expected to read back jane, got %q, %v", name, err)
+			}
+
+			if _, err := db.Exec("FAIL"); err == nil {
+				t.Fatal("Expected an error")
+			}
+
+			assertLines(t, buf.Messages("app:database"), []string{
+				`^exec INSERT INTO users \(name\) VALUES \(\?\) \["jane"\] \S+ rows_affected=1$`,
+				`^query SELECT name FROM users \S+$`,
+				`^exec FAIL \S+ error: boom$`,
+			})
+		})
+	}
+}
+
+func TestTransactions(t *testing.T) {
+	buf := debuggotest.Capture(t, DefaultNamespace)
+	db := openDB(t, &fakeDriver{}, Options{})
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Exec("INSERT INTO users (name) VALUES (?)", "jane")
+	tx.Commit()
+
+	tx, _ = db.BeginTx(context.Background(), nil)
+	tx.Rollback()
+
+	assertLines(t, buf.Messages(DefaultNamespace), []string{
+		`^begin \S+$`,
+		`^exec INSERT INTO users \(name\) VALUES \(\?\) \["jane"\] \S+ rows_affected=1$`,
+		`^commit \S+$`,
+		`^begin \S+$`,
+		`^rollback \S+$`,
+	})
+}
+
+func TestRedactArgs(t *testing.T) {
+	buf := debuggotest.Capture(t, "app:database")
+	db := openDB(t, &fakeDriver{}, Options{Namespace: "app:database", RedactArgs: true})
+
+	db.Exec("INSERT INTO users (name) VALUES (?)", "jane")
+	db.Exec("INSERT INTO users (name) VALUES (:name)", sql.Named("name", "john"))
+
+	assertLines(t, buf.Messages("app:database"), []string{
+		`\[\[REDACTED\]\]`,
+		`\[:name=\[REDACTED\]\]`,
+	})
+	if strings.Contains(buf.String(), "jane") || strings.Contains(buf.String(), "john") {
+		t.Errorf("Expected arguments to be hidden, got %q", buf.String())
+	}
+}
+
+func TestWrapConnector(t *testing.T) {
+	debuggotest.Capture(t, "app:database")
+
+	db := sql.OpenDB(WrapConnector(fakeConnector{&fakeDriver{}}, Options{Namespace: "app:database"}))
+	defer db.Close()
+
+	db.Exec("INSERT INTO users (name) VALUES (?)", "jane")
+	debuggotest.AssertLogged(t, "app:database", `exec INSERT INTO users (name) VALUES (?) ["jane"]`)
+}
+
+func TestWrapConnectorClose(t *testing.T) {
+	c := &closingConnector{fakeConnector: fakeConnector{&fakeDriver{}}}
+	db := sql.OpenDB(WrapConnector(c, Options{Namespace: "app:database"}))
+
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !c.closed {
+		t.Error("Expected closing the database to close the wrapped connector")
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	buf := debuggotest.Capture(t, "app:other")
+	db := openDB(t, &fakeDriver{}, Options{Namespace: "app:database"})
+
+	if _, err := db.Exec("INSERT INTO users (name) VALUES (?)", "jane"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("FAIL"); err == nil {
+		t.Fatal("Expected errors to pass through when disabled")
+	}
+	if len(buf.Records()) != 0 {
+		t.Errorf("Expected no output, got %q", buf.String())
+	}
+}
+
+func TestFormatArg(t *testing.T) {
+	testCases := []struct {
+		description string
+		value       interface{}
+		expected    string
+	}{
+		{"nil", nil, "NULL"},
+		{"int", int64(42), "42"},
+		{"string", "jane", `"jane"`},
+		{"long string", strings.Repeat("a", 70), `"` + strings.Repeat("a", 64) + `"... (70 bytes)`},
+		{"text bytes", []byte("hi"), `"hi"`},
+		{"binary bytes", []byte{0xff, 0x00}, "<2 bytes>"},
+		{"time", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), "2024-01-02T03:04:05Z"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			if got := formatArg(tc.value); got != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package debuggosql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+// fakeDriver is an in-process driver for a single table of names. It
+// understands three statements: "INSERT ..." adds its argument, "SELECT ..."
+// returns every name and "FAIL" returns an error. With legacy set, its
+// connections only support prepared statements, like old drivers.
+type fakeDriver struct {
+	legacy bool
+
+	mu    sync.Mutex
+	names []string
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) {
+	c := &legacyConn{d: d}
+	if d.legacy {
+		return c, nil
+	}
+	return &modernConn{legacyConn: c}, nil
+}
+
+// fakeConnector opens connections to a fakeDriver for sql.OpenDB.
+type fakeConnector struct {
+	d *fakeDriver
+}
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return c.d.Open("") }
+func (c fakeConnector) Driver() driver.Driver                        { return c.d }
+
+// closingConnector is a fakeConnector that records being closed.
+type closingConnector struct {
+	fakeConnector
+	closed bool
+}
+
+func (c *closingConnector) Close() error {
+	c.closed = true
+	return nil
+}
+
+// run executes a statement against the fake table.
+func (d *fakeDriver) run(query string, args []driver.NamedValue) (driver.Result, driver.Rows, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(query, "INSERT"):
+		for _, arg := range args {
+			d.names = append(d.names, arg.Value.(string))
+		}
+		return driver.RowsAffected(len(args)), nil, nil
+	case strings.HasPrefix(query, "SELECT"):
+		return nil, &fakeRows{names: append([]string(nil), d.names...)}, nil
+	default:
+		return nil, nil, errors.New("boom")
+	}
+}
+
+type legacyConn struct {
+	d *fakeDriver
+}
+
+func (c *legacyConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{d: c.d, query: query}, nil
+}
+func (c *legacyConn) Close() error              { return nil }
+func (c *legacyConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+type modernConn struct {
+	*legacyConn
+}
+
+func (c *modernConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	result, _, err := c.d.run(query, args)
+	return result, err
+}
+
+func (c *modernConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	_, rows, err := c.d.run(query, args)
+	return rows, err
+}
+
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	result, _, err := s.d.run(s.query, namedValues(args))
+	return result, err
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	_, rows, err := s.d.run(s.query, namedValues(args))
+	return rows, err
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	names []string
+}
+
+func (r *fakeRows) Columns() []string { return []string{"name"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.names) == 0 {
+		return io.EOF
+	}
+	dest[0], r.names = r.names[0], r.names[1:]
+	return nil
+}
@@ -0,0 +1,88 @@
+package debuggosql
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+)
+
+// stmt wraps a prepared driver.Stmt and logs each execution with the query
+// it was prepared from.
+type stmt struct {
+	driver.Stmt
+	conn  *conn
+	query string
+	log   *queryLog
+}
+
+// Exec implements the driver.Stmt interface.
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+// Query implements the driver.Stmt interface.
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+// ExecContext implements the driver.StmtExecContext interface.
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if !s.log.enabled() {
+		return s.execContext(ctx, args)
+	}
+
+	start := time.Now()
+	result, err := s.execContext(ctx, args)
+	s.log.done(ctx, "exec", s.query, args, start, result, err)
+	return result, err
+}
+
+func (s *stmt) execContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if ec, ok := s.Stmt.(driver.StmtExecContext); ok {
+		return ec.ExecContext(ctx, args)
+	}
+	vals, err := values(args)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.Stmt.Exec(vals)
+}
+
+// QueryContext implements the driver.StmtQueryContext interface.
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if !s.log.enabled() {
+		return s.queryContext(ctx, args)
+	}
+
+	start := time.Now()
+	rows, err := s.queryContext(ctx, args)
+	s.log.done(ctx, "query", s.query, args, start, nil, err)
+	return rows, err
+}
+
+func (s *stmt) queryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if qc, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		return qc.QueryContext(ctx, args)
+	}
+	vals, err := values(args)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.Stmt.Query(vals)
+}
+
+// CheckNamedValue implements the driver.NamedValueChecker interface.
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if nc, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return nc.CheckNamedValue(nv)
+	}
+	// database/sql would ask the connection next. Statements relying on the
+	// deprecated driver.ColumnConverter get the default conversion instead.
+	return s.conn.CheckNamedValue(nv)
+}
@@ -0,0 +1,274 @@
+// Package debuggotest provides helpers for testing code that uses debuggo.
+//
+// Capture enables a DEBUG spec and collects debug output in memory for the
+// lifetime of a test, restoring the previous settings when the test ends.
+// This replaces swapping os.Stderr for a pipe and sleeping until the output
+// arrives. LogTo instead forwards debug output to the test's log, so it is
+// shown alongside the failure of the test that produced it.
+//
+// # Basic Usage
+//
+//	func TestConnect(t *testing.T) {
+//	    debuggotest.Capture(t, "app:db:*")
+//
+//	    connect()
+//
+//	    debuggotest.AssertLogged(t, "app:db:pool", "connection established")
+//	}
+//
+// Capture and LogTo change package-wide debuggo settings, so tests using them
+// must not call t.Parallel. Loggers given their own output or formatter with
+// Logger.WithOutput or Logger.WithFormatter are not captured.
+package debuggotest
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/GeoffreyPlitt/debuggo"
+)
+
+// Buffer holds the debug output captured by Capture.
+// It is safe for concurrent use.
+type Buffer struct {
+	mu      sync.Mutex
+	text    bytes.Buffer
+	records []debuggo.Record
+}
+
+var (
+	current   *Buffer
+	currentMu sync.Mutex
+)
+
+// Capture enables the namespaces in spec (DEBUG syntax) and sends all debug
+// output to a new Buffer until the test and its cleanups finish. The DEBUG
+// environment variable is set to spec for the same period, so code under
+// test that calls debuggo.ReloadDebugSettings sees the same settings.
+//
+// The returned Buffer also becomes the one checked by AssertLogged and
+// AssertNotLogged. Captures nest: when a capture ends, the previous one is
+// active again.
+func Capture(t testing.TB, spec string) *Buffer {
+	t.Helper()
+
+	b := &Buffer{}
+
+	currentMu.Lock()
+	prevBuffer := current
+	current = b
+	currentMu.Unlock()
+
+	install(t, spec, bufferWriter{b}, recordingFormatter{b})
+
+	t.Cleanup(func() {
+		currentMu.Lock()
+		current = prevBuffer
+		currentMu.Unlock()
+	})
+
+	return b
+}
+
+// LogTo enables the namespaces in spec (DEBUG syntax) until the test and its
+// cleanups finish, and forwards every debug line to t.Log so it is reported
+// with the test, and only shown for failing tests unless -v is used. Each
+// line is attributed to the code that logged it rather than to debuggo.
+//
+// As with Capture, DEBUG is set to spec for the same period and the test must
+// not call t.Parallel.
+//
+// Example:
+//
+//	func TestRetry(t *testing.T) {
+//	    debuggotest.LogTo(t, "app:client:*")
+//	    ...
+//	}
+func LogTo(t testing.TB, spec string) {
+	t.Helper()
+	install(t, spec, NewWriter(t), nil)
+}
+
+// NewWriter returns an io.Writer that forwards each debug line to t.Logf,
+// for use with debuggo.SetOutput or Logger.WithOutput. Lines are attributed
+// to the code that logged them rather than to debuggo.
+//
+// Example:
+//
+//	logger := debuggo.New("app:cache").WithOutput(debuggotest.NewWriter(t))
+func NewWriter(t testing.TB) io.Writer {
+	return tbWriter{t}
+}
+
+// tbWriter forwards debug lines to a test log. Embedding testing.TB provides
+// the Helper method debuggo calls to keep its frames out of the attribution.
+type tbWriter struct {
+	testing.TB
+}
+
+// Write implements the io.Writer interface.
+func (w tbWriter) Write(p []byte) (int, error) {
+	w.TB.Helper()
+	w.TB.Logf("%s", bytes.TrimSuffix(p, []byte("\n")))
+	return len(p), nil
+}
+
+// install enables spec and routes debug output to out, formatted by f (or
+// the current formatter if f is nil), restoring the previous settings when
+// the test finishes.
+func install(t testing.TB, spec string, out io.Writer, f debuggo.Formatter) {
+	t.Helper()
+
+	// Setenv fails the test if it is parallel, which cannot be supported
+	// while debuggo's settings are package-wide
+	t.Setenv("DEBUG", spec)
+
+	prevSpec := debuggo.Disable()
+	debuggo.Enable(spec)
+	prevOutput := debuggo.SetOutput(out)
+	var prevFormatter debuggo.Formatter
+	if f != nil {
+		prevFormatter = debuggo.SetFormatter(f)
+	}
+
+	t.Cleanup(func() {
+		if f != nil {
+			debuggo.SetFormatter(prevFormatter)
+		}
+		debuggo.SetOutput(prevOutput)
+		debuggo.Enable(prevSpec)
+	})
+}
+
+// bufferWriter appends formatted output to the Buffer's text under its lock.
+type bufferWriter struct {
+	b *Buffer
+}
+
+// Write implements the io.Writer interface.
+func (w bufferWriter) Write(p []byte) (int, error) {
+	w.b.mu.Lock()
+	defer w.b.mu.Unlock()
+	return w.b.text.Write(p)
+}
+
+// recordingFormatter renders lines as text into the Buffer's output while
+// keeping a copy of each record for assertions.
+type recordingFormatter struct {
+	b *Buffer
+}
+
+// Format implements the debuggo.Formatter interface.
+func (f recordingFormatter) Format(buf *bytes.Buffer, r *debuggo.Record) {
+	debuggo.TextFormatter{}.Format(buf, r)
+
+	f.b.mu.Lock()
+	defer f.b.mu.Unlock()
+	record := *r
+	record.Fields = append([]debuggo.Field(nil), r.Fields...)
+	f.b.records = append(f.b.records, record)
+}
+
+// Records returns a copy of the captured records, oldest first.
+func (b *Buffer) Records() []debuggo.Record {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]debuggo.Record(nil), b.records...)
+}
+
+// Messages returns the messages logged under namespace, oldest first.
+// An empty namespace matches every record.
+func (b *Buffer) Messages(namespace string) []string {
+	var messages []string
+	for _, r := range b.Records() {
+		if namespace == "" || r.Namespace == namespace {
+			messages = append(messages, r.Message)
+		}
+	}
+	return messages
+}
+
+// String returns the captured output as it would have been printed with the
+// default text formatter.
+func (b *Buffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.text.String()
+}
+
+// Reset discards everything captured so far.
+func (b *Buffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.text.Reset()
+	b.records = nil
+}
+
+// Contains reports whether a message containing substring was logged under
+// namespace. An empty namespace matches any namespace.
+func (b *Buffer) Contains(namespace, substring string) bool {
+	for _, msg := range b.Messages(namespace) {
+		if strings.Contains(msg, substring) {
+			return true
+		}
+	}
+	return false
+}
+
+// AssertLogged fails the test unless a message containing substring was
+// logged under namespace into this Buffer.
+func (b *Buffer) AssertLogged(t testing.TB, namespace, substring string) {
+	t.Helper()
+	if !b.Contains(namespace, substring) {
+		t.Errorf("Expected %q to be logged under %s, captured:\n%s", substring, describe(namespace), b.String())
+	}
+}
+
+// AssertNotLogged fails the test if a message containing substring was
+// logged under namespace into this Buffer.
+func (b *Buffer) AssertNotLogged(t testing.TB, namespace, substring string) {
+	t.Helper()
+	if b.Contains(namespace, substring) {
+		t.Errorf("Expected %q not to be logged under %s, captured:\n%s", substring, describe(namespace), b.String())
+	}
+}
+
+// AssertLogged fails the test unless a message containing substring was
+// logged under namespace into the active capture. An empty namespace matches
+// any namespace.
+func AssertLogged(t testing.TB, namespace, substring string) {
+	t.Helper()
+	active(t).AssertLogged(t, namespace, substring)
+}
+
+// AssertNotLogged fails the test if a message containing substring was
+// logged under namespace into the active capture. An empty namespace matches
+// any namespace.
+func AssertNotLogged(t testing.TB, namespace, substring string) {
+	t.Helper()
+	active(t).AssertNotLogged(t, namespace, substring)
+}
+
+// active returns the Buffer of the innermost running Capture.
+func active(t testing.TB) *Buffer {
+	t.Helper()
+
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	if current == nil {
+		t.Fatal("debuggotest: no active capture; call Capture first")
+	}
+	return current
+}
+
+// describe names a namespace argument in failure messages.
+func describe(namespace string) string {
+	if namespace == "" {
+		return "any namespace"
+	}
+	return strconv.Quote(namespace)
+}
@@ -0,0 +1,222 @@
+package debuggotest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/GeoffreyPlitt/debuggo"
+)
+
+// fakeT records failures instead of failing the real test.
+type fakeT struct {
+	testing.TB
+	failed bool
+	output string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.failed = true
+	f.output = format
+}
+
+func TestCapture(t *testing.T) {
+	buf := Capture(t, "app:*,!app:metrics")
+
+	debuggo.Debug("app:db")("Connected to %s", "postgres")
+	debuggo.Debug("app:metrics")("Hidden")
+	debuggo.New("app:api").PrintfContext(
+		debuggo.WithContext(context.Background(), debuggo.F("request_id", 7)), "Handled")
+
+	AssertLogged(t, "app:db", "Connected to postgres")
+	AssertLogged(t, "", "Handled")
+	AssertNotLogged(t, "app:metrics", "Hidden")
+	AssertNotLogged(t, "app:api", "Connected")
+
+	records := buf.Records()
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %v", records)
+	}
+	if len(records[1].Fields) != 1 || records[1].Fields[0].Key != "request_id" {
+		t.Errorf("Expected fields to be captured, got %v", records[1].Fields)
+	}
+
+	if !strings.HasSuffix(buf.String(), " app:api Handled request_id=7\n") {
+		t.Errorf("Expected text output, got %q", buf.String())
+	}
+
+	if os.Getenv("DEBUG") != "app:*,!app:metrics" {
+		t.Errorf("Expected DEBUG to be set for the test, got %q", os.Getenv("DEBUG"))
+	}
+
+	buf.Reset()
+	if len(buf.Records()) != 0 || buf.String() != "" {
+		t.Error("Expected Reset to discard captured output")
+	}
+}
+
+func TestCaptureRestoresSettings(t *testing.T) {
+	debuggo.Enable("outer")
+
+	t.Run("capture", func(t *testing.T) {
+		Capture(t, "inner")
+		if !debuggo.IsEnabled("inner") || debuggo.IsEnabled("outer") {
+			t.Error("Expected the capture spec to be active")
+		}
+	})
+
+	if !debuggo.IsEnabled("outer") || debuggo.IsEnabled("inner") {
+		t.Error("Expected the previous spec to be restored after the test")
+	}
+
+	// The restored output is stderr again, so nothing is captured
+	debuggo.Disable()
+}
+
+func TestNestedCaptures(t *testing.T) {
+	outer := Capture(t, "app")
+
+	t.Run("inner", func(t *testing.T) {
+		inner := Capture(t, "app")
+		debuggo.Debug("app")("inner message")
+
+		AssertLogged(t, "app", "inner message")
+		outer.AssertNotLogged(t, "app", "inner message")
+		inner.AssertLogged(t, "app", "inner message")
+	})
+
+	debuggo.Debug("app")("outer message")
+	AssertLogged(t, "app", "outer message")
+}
+
+func TestAssertionsFail(t *testing.T) {
+	buf := Capture(t, "app")
+	debuggo.Debug("app")("present")
+
+	ft := &fakeT{}
+	buf.AssertLogged(ft, "app", "absent")
+	if !ft.failed {
+		t.Error("Expected AssertLogged to fail for a missing message")
+	}
+
+	ft = &fakeT{}
+	buf.AssertLogged(ft, "other", "present")
+	if !ft.failed {
+		t.Error("Expected AssertLogged to fail for the wrong namespace")
+	}
+
+	ft = &fakeT{}
+	buf.AssertNotLogged(ft, "", "present")
+	if !ft.failed {
+		t.Error("Expected AssertNotLogged to fail for a logged message")
+	}
+}
+
+func TestCaptureConcurrentLogging(t *testing.T) {
+	buf := Capture(t, "app")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				debuggo.Debug("app")("message")
+				_ = buf.String()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := len(buf.Messages("app")); n != 100 {
+		t.Errorf("Expected 100 messages, got %d", n)
+	}
+}
+
+// here returns the line number it was called from.
+func here() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+// TestLogToChild is run in a subprocess by TestLogToAttribution, since the
+// location prefix t.Log adds can only be observed from outside the test.
+func TestLogToChild(t *testing.T) {
+	if os.Getenv("DEBUGGOTEST_CHILD") == "" {
+		t.Skip("only run as a subprocess")
+	}
+
+	LogTo(t, "app:*")
+
+	debug := debuggo.Debug("app:debug")
+	logger := debuggo.New("app:logger")
+	ctx := debuggo.WithContext(context.Background(), debuggo.F("id", 1))
+
+	debug("via Debug at %d", here())
+	logger.Printf("via Printf at %d", here())
+	logger.Println("via Println at", here())
+	logger.Print("via Print at ", here())
+	logger.Write([]byte(fmt.Sprintf("via Write at %d\n", here())))
+	debuggo.DebugContext("app:ctx")(ctx, "via DebugContext at %d", here())
+	debuggo.Debug("other")("disabled at %d", here())
+}
+
+func TestLogToAttribution(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLogToChild$", "-test.v")
+	cmd.Env = append(os.Environ(), "DEBUGGOTEST_CHILD=1")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Child test failed: %v\n%s", err, out)
+	}
+
+	pattern := regexp.MustCompile(`debuggotest_test\.go:(\d+): \S+ (\S+) via (\w+) at (\d+)`)
+	matches := pattern.FindAllStringSubmatch(string(out), -1)
+	if len(matches) != 6 {
+		t.Fatalf("Expected 6 attributed lines, got %d in:\n%s", len(matches), out)
+	}
+
+	for _, m := range matches {
+		if m[1] != m[4] {
+			t.Errorf("Expected %s line to be attributed to line %s, got line %s", m[3], m[4], m[1])
+		}
+	}
+
+	if strings.Contains(string(out), "disabled at") {
+		t.Errorf("Expected disabled namespaces not to be logged:\n%s", out)
+	}
+	if !strings.Contains(string(out), "id=1") {
+		t.Errorf("Expected context fields in the test log:\n%s", out)
+	}
+}
+
+func TestNewWriter(t *testing.T) {
+	rec := &logRecorder{TB: t}
+	debuggo.Enable("app")
+	defer debuggo.Disable()
+
+	debuggo.New("app").WithOutput(NewWriter(rec)).Printf("hello %s", "test")
+
+	if len(rec.lines) != 1 || !strings.HasSuffix(rec.lines[0], " app hello test") {
+		t.Errorf("Expected one forwarded line without a trailing newline, got %q", rec.lines)
+	}
+}
+
+// logRecorder captures Logf calls.
+type logRecorder struct {
+	testing.TB
+	lines []string
+}
+
+func (r *logRecorder) Helper() {}
+
+func (r *logRecorder) Logf(format string, args ...interface{}) {
+	r.lines = append(r.lines, fmt.Sprintf(format, args...))
+}
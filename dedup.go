@@ -0,0 +1,121 @@
+package debuggo
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// dedupWindow is the window set by EnableDedup; zero means off
+	dedupWindow atomic.Int64
+	dedupMu     sync.Mutex
+	dedupState  = map[string]*dedupEntry{}
+	// dedupAfterFunc starts the timer ending a window, replaceable in tests
+	dedupAfterFunc = time.AfterFunc
+)
+
+// dedupEntry tracks the last line written by one namespace and how many
+// identical lines have been held back since.
+type dedupEntry struct {
+	namespace string
+	message   string
+	repeats   int
+	logger    *Logger
+	timer     *time.Timer
+}
+
+// EnableDedup collapses consecutive identical messages from the same
+// namespace, like syslog does. The first message is written as usual; exact
+// repeats that follow are held back and counted, and a single "last message
+// repeated N times" line is written once window has passed since the first
+// repeat, or earlier if the namespace logs a different message. Messages are
+// compared by their text only, not their fields.
+//
+// Calling it again changes the window; a window of zero or less turns
+// deduplication off. Pending counts are reported when either happens.
+//
+// Example:
+//
+//	debuggo.EnableDedup(5 * time.Second)
+func EnableDedup(window time.Duration) {
+	if window < 0 {
+		window = 0
+	}
+
+	dedupMu.Lock()
+	pending := dedupState
+	dedupState = map[string]*dedupEntry{}
+	dedupWindow.Store(int64(window))
+	dedupMu.Unlock()
+
+	for _, e := range pending {
+		e.flush()
+	}
+}
+
+// dedup reports whether r repeats the previous message of the logger's
+// namespace and should not be written. When r differs, the count of any
+// repeats it ends is written first.
+func (l *Logger) dedup(r *Record) bool {
+	window := time.Duration(dedupWindow.Load())
+	if window <= 0 {
+		return false
+	}
+
+	dedupMu.Lock()
+	prev := dedupState[l.namespace]
+	if prev != nil && prev.message == r.Message {
+		prev.repeats++
+		prev.logger = l
+		if prev.timer == nil {
+			prev.timer = dedupAfterFunc(window, prev.expire)
+		}
+		dedupMu.Unlock()
+		return true
+	}
+	dedupState[l.namespace] = &dedupEntry{namespace: l.namespace, message: r.Message}
+	dedupMu.Unlock()
+
+	if prev != nil {
+		prev.flush()
+	}
+	return false
+}
+
+// expire reports the repeats at the end of the window and forgets the
+// message, so the next identical one is written in full.
+func (e *dedupEntry) expire() {
+	dedupMu.Lock()
+	if dedupState[e.namespace] == e {
+		delete(dedupState, e.namespace)
+	}
+	dedupMu.Unlock()
+
+	e.flush()
+}
+
+// flush writes the "last message repeated N times" line if any repeats were
+// held back.
+func (e *dedupEntry) flush() {
+	dedupMu.Lock()
+	n, logger := e.repeats, e.logger
+	e.repeats = 0
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	}
+	dedupMu.Unlock()
+
+	if n == 0 {
+		return
+	}
+
+	noun := "times"
+	if n == 1 {
+		noun = "time"
+	}
+	r := logger.record(fmt.Sprintf("last message repeated %d %s", n, noun), nil)
+	logger.write(&r)
+}
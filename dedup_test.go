@@ -0,0 +1,119 @@
+package debuggo
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDedup(t *testing.T) {
+	Enable("app:*")
+	defer Disable()
+
+	EnableDedup(time.Hour)
+	defer EnableDedup(0)
+
+	testCases := []struct {
+		description string
+		messages    []string
+		expected    string
+	}{
+		{
+			description: "repeats are collapsed",
+			messages:    []string{"retrying", "retrying", "retrying", "done"},
+			expected:    "retrying\nlast message repeated 2 times\ndone\n",
+		},
+		{
+			description: "single repeat",
+			messages:    []string{"retrying", "retrying", "done"},
+			expected:    "retrying\nlast message repeated 1 time\ndone\n",
+		},
+		{
+			description: "non-consecutive duplicates are kept",
+			messages:    []string{"a", "b", "a", "b"},
+			expected:    "a\nb\na\nb\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			buf := &syncBuffer{}
+			logger := New("app:dedup:" + tc.description).WithOutput(buf).WithFormatter(messageOnly{})
+			for _, m := range tc.messages {
+				logger.Print(m)
+			}
+
+			if got := buf.String(); got != tc.expected {
+				t.Errorf("Expected output %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestDedupPerNamespace(t *testing.T) {
+	Enable("app:*")
+	defer Disable()
+
+	EnableDedup(time.Hour)
+
+	buf := &syncBuffer{}
+	a := New("app:a").WithOutput(buf).WithFormatter(messageOnly{})
+	b := New("app:b").WithOutput(buf).WithFormatter(messageOnly{})
+
+	a.Print("same")
+	b.Print("same")
+	a.Print("same")
+	b.Print("same")
+
+	if got := buf.String(); got != "same\nsame\n" {
+		t.Errorf("Expected one line per namespace, got %q", got)
+	}
+
+	// Turning dedup off reports what is still pending
+	EnableDedup(0)
+	expected := "same\nsame\nlast message repeated 1 time\nlast message repeated 1 time\n"
+	if got := buf.String(); got != expected {
+		t.Errorf("Expected output %q, got %q", expected, got)
+	}
+
+	a.Print("same")
+	if got := strings.Count(buf.String(), "same\n"); got != 3 {
+		t.Errorf("Expected repeats to be written once dedup is off, got %q", buf.String())
+	}
+}
+
+func TestDedupWindow(t *testing.T) {
+	Enable("app:*")
+	defer Disable()
+
+	// Capture the end of the window instead of waiting for it
+	var windows []time.Duration
+	var expire func()
+	defer func(prev func(time.Duration, func()) *time.Timer) { dedupAfterFunc = prev }(dedupAfterFunc)
+	dedupAfterFunc = func(d time.Duration, f func()) *time.Timer {
+		windows, expire = append(windows, d), f
+		return time.AfterFunc(time.Hour, func() {})
+	}
+
+	EnableDedup(10 * time.Millisecond)
+	defer EnableDedup(0)
+
+	buf := &syncBuffer{}
+	logger := New("app:window").WithOutput(buf).WithFormatter(messageOnly{})
+	logger.Print("tick")
+	logger.Print("tick")
+	logger.Print("tick")
+
+	if len(windows) != 1 || windows[0] != 10*time.Millisecond {
+		t.Fatalf("Expected one 10ms window to start with the first repeat, got %v", windows)
+	}
+	expire()
+
+	// After the window the message is written in full again
+	logger.Print("tick")
+
+	expected := "tick\nlast message repeated 2 times\ntick\n"
+	if got := buf.String(); got != expected {
+		t.Errorf("Expected output %q, got %q", expected, got)
+	}
+}
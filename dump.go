@@ -0,0 +1,392 @@
+package debuggo
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Default limits for Dump, used when the matching DumpOptions field is zero.
+const (
+	DefaultDumpDepth        = 8
+	DefaultDumpItems        = 50
+	DefaultDumpStringLength = 200
+	DefaultHexDumpBytes     = 4096
+)
+
+// DumpOptions limits how much of a value Dump and HexDump print.
+type DumpOptions struct {
+	// MaxDepth is how many levels of nesting are expanded; deeper values are
+	// shown as {...}. Defaults to DefaultDumpDepth.
+	MaxDepth int
+	// MaxItems is how many elements of a slice, array, map or struct are
+	// shown. Defaults to DefaultDumpItems.
+	MaxItems int
+	// MaxStringLength is how many bytes of a string or []byte are shown.
+	// Defaults to DefaultDumpStringLength.
+	MaxStringLength int
+	// MaxBytes is how many bytes HexDump shows. Defaults to
+	// DefaultHexDumpBytes.
+	MaxBytes int
+}
+
+var (
+	dumpOptions   DumpOptions
+	dumpOptionsMu sync.RWMutex
+)
+
+// SetDumpOptions changes the limits used by Dump and HexDump and returns
+// the previous options so that they can be restored later.
+//
+// Example:
+//
+//	debuggo.SetDumpOptions(debuggo.DumpOptions{MaxDepth: 3, MaxItems: 10})
+func SetDumpOptions(opts DumpOptions) (previous DumpOptions) {
+	dumpOptionsMu.Lock()
+	defer dumpOptionsMu.Unlock()
+	previous, dumpOptions = dumpOptions, opts
+	return previous
+}
+
+// Dump logs value under namespace as an indented tree annotated with types,
+// one debug line per row. Pointers are followed, cycles are marked, map keys
+// are sorted, and fields tagged `debug:"redact"` are masked:
+//
+//	debuggo.Dump("app:config", "config", cfg)
+//
+//	12:34:56.789 app:config config: (*main.Config) {
+//	12:34:56.789 app:config   Name: (string) "api"
+//	12:34:56.789 app:config   Ports: ([]int) (len=2) [
+//	12:34:56.789 app:config     0: (int) 80
+//	12:34:56.789 app:config     1: (int) 443
+//	12:34:56.789 app:config   ]
+//	12:34:56.789 app:config   Password: (string) [REDACTED]
+//	12:34:56.789 app:config }
+//
+// Values implementing error or fmt.Stringer are shown using their own text.
+// A Limit on namespace keeps or drops all the rows of a dump together.
+// Nothing is computed when namespace is disabled. See SetDumpOptions for the
+// depth and length limits.
+func Dump(namespace, label string, value interface{}) {
+	New(namespace).Dump(label, value)
+}
+
+// Dump is the Logger equivalent of the package-level Dump function.
+func (l *Logger) Dump(label string, value interface{}) {
+	// Check first to avoid walking value when nothing will use it
+	if !IsEnabled(l.namespace) && activeRecorder() == nil {
+		return
+	}
+	if h := l.helper(); h != nil {
+		h.Helper()
+	}
+
+	d := &dumper{opts: currentDumpOptions(), visiting: map[dumpRef]bool{}}
+
+	prefix := ""
+	if label != "" {
+		prefix = label + ": "
+	}
+	d.value(prefix, reflect.ValueOf(value), 0)
+
+	// Rows are written or held back by a Limit together, so a tree is never
+	// shown with rows missing
+	enabled := l.allowed()
+	for _, line := range d.lines {
+		l.emit(nil, enabled, message{format: line, mode: modeText})
+	}
+}
+
+// dumper renders a value as the lines of a Dump.
+type dumper struct {
+	opts  DumpOptions
+	lines []string
+	// visiting holds the pointers, maps and slices on the current path, to
+	// detect cycles
+	visiting map[dumpRef]bool
+}
+
+// dumpRef identifies a value that can refer back to itself.
+type dumpRef struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// currentDumpOptions returns the options set with SetDumpOptions, with
+// defaults filled in.
+func currentDumpOptions() DumpOptions {
+	dumpOptionsMu.RLock()
+	opts := dumpOptions
+	dumpOptionsMu.RUnlock()
+
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = DefaultDumpDepth
+	}
+	if opts.MaxItems <= 0 {
+		opts.MaxItems = DefaultDumpItems
+	}
+	if opts.MaxStringLength <= 0 {
+		opts.MaxStringLength = DefaultDumpStringLength
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultHexDumpBytes
+	}
+	return opts
+}
+
+// line adds a row at the given depth.
+func (d *dumper) line(depth int, text string) {
+	d.lines = append(d.lines, strings.Repeat(spanIndent, depth)+text)
+}
+
+// value adds the rows for v, starting with prefix on the first one.
+func (d *dumper) value(prefix string, v reflect.Value, depth int) {
+	if !v.IsValid() {
+		d.line(depth, prefix+"nil")
+		return
+	}
+	// Show the dynamic type of interface values
+	for v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			d.line(depth, prefix+"("+v.Type().String()+") nil")
+			return
+		}
+		v = v.Elem()
+	}
+
+	head := prefix + "(" + v.Type().String() + ") "
+	if text, ok := selfText(v); ok {
+		d.line(depth, head+text)
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			d.line(depth, head+"nil")
+			return
+		}
+		if !d.enter(v) {
+			d.line(depth, head+"<cycle>")
+			return
+		}
+		defer d.leave(v)
+		elem := v.Elem()
+		if isContainer(elem) {
+			// Show the pointed-to value on the same row
+			d.container(head, elem, depth)
+			return
+		}
+		d.line(depth, head+"&"+d.scalar(elem))
+	case reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			d.line(depth, head+"nil")
+			return
+		}
+		if !d.enter(v) {
+			d.line(depth, head+"<cycle>")
+			return
+		}
+		defer d.leave(v)
+		d.container(head, v, depth)
+	case reflect.Array, reflect.Struct:
+		d.container(head, v, depth)
+	default:
+		d.line(depth, head+d.scalar(v))
+	}
+}
+
+// container adds the rows for a struct, map, slice or array.
+func (d *dumper) container(head string, v reflect.Value, depth int) {
+	opening, closing := "{", "}"
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			d.line(depth, head+fmt.Sprintf("(len=%d) ", v.Len())+d.bytes(v))
+			return
+		}
+		opening, closing = "[", "]"
+		head += fmt.Sprintf("(len=%d) ", v.Len())
+	case reflect.Map:
+		head += fmt.Sprintf("(len=%d) ", v.Len())
+	}
+
+	var n int
+	if v.Kind() == reflect.Struct {
+		n = v.NumField()
+	} else {
+		n = v.Len()
+	}
+	if n == 0 {
+		d.line(depth, head+opening+closing)
+		return
+	}
+	if depth >= d.opts.MaxDepth {
+		d.line(depth, head+opening+"..."+closing)
+		return
+	}
+
+	d.line(depth, head+opening)
+	shown := n
+	if shown > d.opts.MaxItems {
+		shown = d.opts.MaxItems
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < shown; i++ {
+			f := t.Field(i)
+			if isRedactTag(f.Tag) {
+				d.line(depth+1, f.Name+": ("+f.Type.String()+") "+RedactedText)
+				continue
+			}
+			d.value(f.Name+": ", v.Field(i), depth+1)
+		}
+	case reflect.Map:
+		keys := sortedKeys(v)
+		for _, k := range keys[:shown] {
+			d.value(d.scalar(k)+": ", v.MapIndex(k), depth+1)
+		}
+	default:
+		for i := 0; i < shown; i++ {
+			d.value(strconv.Itoa(i)+": ", v.Index(i), depth+1)
+		}
+	}
+	if n > shown {
+		d.line(depth+1, fmt.Sprintf("... (%d more)", n-shown))
+	}
+	d.line(depth, closing)
+}
+
+// enter marks v as being visited, reporting false if it already is.
+func (d *dumper) enter(v reflect.Value) bool {
+	ref := dumpRef{v.Pointer(), v.Type()}
+	if d.visiting[ref] {
+		return false
+	}
+	d.visiting[ref] = true
+	return true
+}
+
+func (d *dumper) leave(v reflect.Value) {
+	delete(d.visiting, dumpRef{v.Pointer(), v.Type()})
+}
+
+// scalar renders a value on a single line, such as a map key.
+func (d *dumper) scalar(v reflect.Value) string {
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	if text, ok := selfText(v); ok {
+		return text
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return d.quote(v.String())
+	case reflect.Pointer, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		if v.IsNil() {
+			return "nil"
+		}
+		return fmt.Sprintf("%#x", v.Pointer())
+	}
+	// fmt can print unexported values when given the reflect.Value
+	return fmt.Sprint(v)
+}
+
+// quote quotes s, shortened to MaxStringLength bytes.
+func (d *dumper) quote(s string) string {
+	if len(s) <= d.opts.MaxStringLength {
+		return strconv.Quote(s)
+	}
+	cut := d.opts.MaxStringLength
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s... (%d bytes)", strconv.Quote(s[:cut]), len(s))
+}
+
+// bytes renders a byte slice or array as a quoted string when it is text,
+// and in hex otherwise.
+func (d *dumper) bytes(v reflect.Value) string {
+	// Copy element by element, as reflect.Copy rejects named byte types
+	b := make([]byte, v.Len())
+	for i := range b {
+		b[i] = byte(v.Index(i).Uint())
+	}
+	if utf8.Valid(b) {
+		return d.quote(string(b))
+	}
+	if len(b) <= d.opts.MaxStringLength {
+		return fmt.Sprintf("%x", b)
+	}
+	return fmt.Sprintf("%x... (%d bytes)", b[:d.opts.MaxStringLength], len(b))
+}
+
+// selfText returns the text of values implementing error or fmt.Stringer.
+// A method that panics is rendered the way fmt renders it.
+func selfText(v reflect.Value) (text string, ok bool) {
+	if !v.CanInterface() || (v.Kind() == reflect.Pointer && v.IsNil()) {
+		return "", false
+	}
+
+	method := ""
+	defer func() {
+		if p := recover(); p != nil {
+			text, ok = fmt.Sprintf("%%!v(PANIC=%s method: %v)", method, p), true
+		}
+	}()
+	switch s := v.Interface().(type) {
+	case error:
+		method = "Error"
+		return s.Error(), true
+	case fmt.Stringer:
+		method = "String"
+		return s.String(), true
+	}
+	return "", false
+}
+
+// isContainer reports whether v is shown over several rows.
+func isContainer(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Struct, reflect.Array, reflect.Slice, reflect.Map:
+		_, ok := selfText(v)
+		return !ok
+	}
+	return false
+}
+
+// sortedKeys returns the keys of map v in a stable order: numerically or
+// alphabetically when the keys allow it, and by their text otherwise.
+func sortedKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		for a.Kind() == reflect.Interface && !a.IsNil() {
+			a = a.Elem()
+		}
+		for b.Kind() == reflect.Interface && !b.IsNil() {
+			b = b.Elem()
+		}
+		if a.Kind() == b.Kind() {
+			switch a.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				return a.Int() < b.Int()
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+				return a.Uint() < b.Uint()
+			case reflect.Float32, reflect.Float64:
+				return a.Float() < b.Float()
+			case reflect.String:
+				return a.String() < b.String()
+			case reflect.Bool:
+				return !a.Bool() && b.Bool()
+			}
+		}
+		return fmt.Sprint(a) < fmt.Sprint(b)
+	})
+	return keys
+}
@@ -0,0 +1,291 @@
+package debuggo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type dumpUser struct {
+	Name     string
+	Tags     []string
+	Manager  *dumpUser
+	Password string `debug:"redact"`
+	age      int
+}
+
+type dumpByte uint8
+
+// dumpPanics has a String method that dereferences a nil field.
+type dumpPanics struct {
+	name *string
+}
+
+func (p dumpPanics) String() string {
+	return *p.name
+}
+
+type dumpNode struct {
+	Value int
+	Next  *dumpNode
+}
+
+// dumpLines dumps value with opts and returns the lines written.
+func dumpLines(t *testing.T, opts DumpOptions, label string, value interface{}) []string {
+	t.Helper()
+
+	previous := SetDumpOptions(opts)
+	defer SetDumpOptions(previous)
+
+	buf := &syncBuffer{}
+	New("app:dump").WithOutput(buf).WithFormatter(messageOnly{}).Dump(label, value)
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+}
+
+func TestDump(t *testing.T) {
+	Enable("app:dump")
+	defer Disable()
+
+	cycle := &dumpNode{Value: 1}
+	cycle.Next = &dumpNode{Value: 2, Next: cycle}
+	shared := &dumpNode{Value: 3}
+	n := 7
+
+	testCases := []struct {
+		description string
+		opts        DumpOptions
+		value       interface{}
+		expected    []string
+	}{
+		{
+			description: "scalars",
+			value:       42,
+			expected:    []string{"v: (int) 42"},
+		},
+		{
+			description: "nil",
+			value:       nil,
+			expected:    []string{"v: nil"},
+		},
+		{
+			description: "pointer to scalar",
+			value:       &n,
+			expected:    []string{"v: (*int) &7"},
+		},
+		{
+			description: "struct with pointers, redaction and unexported fields",
+			value: &dumpUser{
+				Name:     "ann",
+				Tags:     []string{"admin", "ops"},
+				Manager:  &dumpUser{Name: "bob"},
+				Password: "hunter2",
+				age:      30,
+			},
+			expected: []string{
+				"v: (*debuggo.dumpUser) {",
+				`  Name: (string) "ann"`,
+				"  Tags: ([]string) (len=2) [",
+				`    0: (string) "admin"`,
+				`    1: (string) "ops"`,
+				"  ]",
+				"  Manager: (*debuggo.dumpUser) {",
+				`    Name: (string) "bob"`,
+				"    Tags: ([]string) nil",
+				"    Manager: (*debuggo.dumpUser) nil",
+				"    Password: (string) [REDACTED]",
+				"    age: (int) 0",
+				"  }",
+				"  Password: (string) [REDACTED]",
+				"  age: (int) 30",
+				"}",
+			},
+		},
+		{
+			description: "maps are sorted by key",
+			value:       map[interface{}]int{10: 1, 2: 2, "b": 3, "a": 4},
+			expected: []string{
+				"v: (map[interface {}]int) (len=4) {",
+				"  2: (int) 2",
+				"  10: (int) 1",
+				`  "a": (int) 4`,
+				`  "b": (int) 3`,
+				"}",
+			},
+		},
+		{
+			description: "cycles are marked",
+			value:       cycle,
+			expected: []string{
+				"v: (*debuggo.dumpNode) {",
+				"  Value: (int) 1",
+				"  Next: (*debuggo.dumpNode) {",
+				"    Value: (int) 2",
+				"    Next: (*debuggo.dumpNode) <cycle>",
+				"  }",
+				"}",
+			},
+		},
+		{
+			description: "shared pointers are not cycles",
+			value:       []*dumpNode{shared, shared},
+			expected: []string{
+				"v: ([]*debuggo.dumpNode) (len=2) [",
+				"  0: (*debuggo.dumpNode) {",
+				"    Value: (int) 3",
+				"    Next: (*debuggo.dumpNode) nil",
+				"  }",
+				"  1: (*debuggo.dumpNode) {",
+				"    Value: (int) 3",
+				"    Next: (*debuggo.dumpNode) nil",
+				"  }",
+				"]",
+			},
+		},
+		{
+			description: "interfaces show their dynamic type",
+			value:       []interface{}{1, "two", nil},
+			expected: []string{
+				"v: ([]interface {}) (len=3) [",
+				"  0: (int) 1",
+				`  1: (string) "two"`,
+				"  2: (interface {}) nil",
+				"]",
+			},
+		},
+		{
+			description: "errors and stringers use their own text",
+			value:       []interface{}{errors.New("boom"), 1500 * time.Millisecond},
+			expected: []string{
+				"v: ([]interface {}) (len=2) [",
+				"  0: (*errors.errorString) boom",
+				"  1: (time.Duration) 1.5s",
+				"]",
+			},
+		},
+		{
+			description: "bytes",
+			value:       [][]byte{[]byte("hi"), {0xff, 0x00}},
+			expected: []string{
+				"v: ([][]uint8) (len=2) [",
+				`  0: ([]uint8) (len=2) "hi"`,
+				"  1: ([]uint8) (len=2) ff00",
+				"]",
+			},
+		},
+		{
+			description: "named byte types",
+			value: struct {
+				A []dumpByte
+				B [2]dumpByte
+			}{[]dumpByte("hi"), [2]dumpByte{0xff, 0x00}},
+			expected: []string{
+				"v: (struct { A []debuggo.dumpByte; B [2]debuggo.dumpByte }) {",
+				`  A: ([]debuggo.dumpByte) (len=2) "hi"`,
+				"  B: ([2]debuggo.dumpByte) (len=2) ff00",
+				"}",
+			},
+		},
+		{
+			description: "panicking String methods are reported as by fmt",
+			value:       []dumpPanics{{}},
+			expected: []string{
+				"v: ([]debuggo.dumpPanics) (len=1) [",
+				"  0: (debuggo.dumpPanics) %!v(PANIC=String method: runtime error: invalid memory address or nil pointer dereference)",
+				"]",
+			},
+		},
+		{
+			description: "depth limit",
+			opts:        DumpOptions{MaxDepth: 1},
+			value:       [][]int{{1}, {}},
+			expected: []string{
+				"v: ([][]int) (len=2) [",
+				"  0: ([]int) (len=1) [...]",
+				"  1: ([]int) (len=0) []",
+				"]",
+			},
+		},
+		{
+			description: "item limit",
+			opts:        DumpOptions{MaxItems: 2},
+			value:       []int{1, 2, 3, 4},
+			expected: []string{
+				"v: ([]int) (len=4) [",
+				"  0: (int) 1",
+				"  1: (int) 2",
+				"  ... (2 more)",
+				"]",
+			},
+		},
+		{
+			description: "string length limit",
+			opts:        DumpOptions{MaxStringLength: 2},
+			value:       "héllo world",
+			expected:    []string{`v: (string) "h"... (12 bytes)`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			got := dumpLines(t, tc.opts, "v", tc.value)
+			if strings.Join(got, "\n") != strings.Join(tc.expected, "\n") {
+				t.Errorf("Expected:\n%s\ngot:\n%s", strings.Join(tc.expected, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestDumpWithoutLabel(t *testing.T) {
+	Enable("app:dump")
+	defer Disable()
+
+	got := dumpLines(t, DumpOptions{}, "", true)
+	if len(got) != 1 || got[0] != "(bool) true" {
+		t.Errorf("Expected an unlabelled row, got %q", got)
+	}
+}
+
+func TestDumpLimit(t *testing.T) {
+	Enable("app:dump")
+	defer Disable()
+	defer ClearLimits()
+	SetLimit("app:dump", Limit{Sample: 2})
+
+	value := []int{1, 2}
+	buf := &syncBuffer{}
+	l := New("app:dump").WithOutput(buf).WithFormatter(messageOnly{})
+	for i := 0; i < 3; i++ {
+		l.Dump("v", value)
+	}
+
+	// The first and third dumps are kept whole, the second is dropped whole
+	rows := "v: ([]int) (len=2) [\n  0: (int) 1\n  1: (int) 2\n]\n"
+	if buf.String() != rows+rows {
+		t.Errorf("Expected two complete dumps, got %q", buf.String())
+	}
+}
+
+func TestDumpDisabled(t *testing.T) {
+	Enable("app:other")
+	defer Disable()
+
+	buf := &syncBuffer{}
+	l := New("app:dump").WithOutput(buf)
+	l.Dump("v", map[string]int{"a": 1})
+
+	if buf.String() != "" {
+		t.Errorf("Expected no output, got %q", buf.String())
+	}
+
+	check := testing.AllocsPerRun(100, func() {
+		IsEnabled("app:dump")
+	})
+	value := map[string]int{"a": 1}
+	allocs := testing.AllocsPerRun(100, func() {
+		l.Dump("v", value)
+	})
+	if allocs > check {
+		t.Errorf("Expected a disabled dump to allocate at most %v times, got %v", check, allocs)
+	}
+}
@@ -1,6 +1,7 @@
 package debuggo_test
 
 import (
+	"context"
 	"os"
 
 	"github.com/GeoffreyPlitt/debuggo"
@@ -61,3 +62,33 @@ func Example_wildcardAndNegation() {
 
 	// Output is sent to stderr
 }
+
+// This example demonstrates child loggers created with Extend
+func ExampleLogger_Extend() {
+	os.Setenv("DEBUG", "app:server:*")
+	debuggo.ReloadDebugSettings()
+
+	server := debuggo.New("app:server")
+	httpDebug := server.Extend("http") // app:server:http
+	wsDebug := server.Extend("ws")     // app:server:ws
+
+	httpDebug.Printf("HTTP server starting on port %d", 8080)
+	wsDebug.Printf("WebSocket server starting on port %d", 8081)
+
+	// Output is sent to stderr
+}
+
+// This example demonstrates attaching fields to every line via a context
+func ExampleWithContext() {
+	os.Setenv("DEBUG", "app:*")
+	debuggo.ReloadDebugSettings()
+
+	debug := debuggo.DebugContext("app:http")
+
+	// Typically done once where a request enters the service
+	ctx := debuggo.WithContext(context.Background(), debuggo.F("request_id", 42))
+
+	debug(ctx, "Handling %s", "/api/users") // ... Handling /api/users request_id=42
+
+	// Output is sent to stderr
+}
@@ -4,26 +4,19 @@ import (
 	"os"
 	"testing"
 
-	"github.com/GeoffreyPlitt/debuggo"
+	"github.com/GeoffreyPlitt/debuggo/debuggotest"
 )
 
 // TestAdvancedExample ensures all functions are executed for coverage
 func TestAdvancedExample(t *testing.T) {
-	// Redirect stderr and stdout to /dev/null
-	origStderr := os.Stderr
+	// Redirect stdout to /dev/null to avoid test output pollution
 	origStdout := os.Stdout
 	devNull, _ := os.Open(os.DevNull)
-	os.Stderr = devNull
 	os.Stdout = devNull
 	defer func() {
-		os.Stderr = origStderr
 		os.Stdout = origStdout
 	}()
 
-	// Save original DEBUG value
-	originalDebug := os.Getenv("DEBUG")
-	defer os.Setenv("DEBUG", originalDebug)
-
 	// Test scenarios that are guaranteed to hit all code paths
 	debugScenarios := []string{
 		"app:*",                   // All app components
@@ -32,16 +25,22 @@ func TestAdvancedExample(t *testing.T) {
 	}
 
 	for _, setting := range debugScenarios {
-		// Set DEBUG and run the main function
-		os.Setenv("DEBUG", setting)
-		debuggo.ReloadDebugSettings()
-
-		// Call main which will execute everything
-		main()
-
-		// Ensure these specific functions are called for coverage
-		reconfigureDebugSettings()
-		simulateHttpRequest("/api/direct-test")
-		simulateWebSocketMessage("direct-test")
+		t.Run(setting, func(t *testing.T) {
+			// Capture sets DEBUG, which main reads and reconfigures at runtime
+			debuggotest.Capture(t, setting)
+
+			// Call main which will execute everything
+			main()
+
+			debuggotest.AssertLogged(t, "app:server:http", "Received HTTP request: /api/users")
+			// After reconfiguration the websocket component is always disabled
+			debuggotest.AssertNotLogged(t, "app:server:websocket", "message-received")
+			debuggotest.AssertLogged(t, "app:database", "Query completed in 25ms")
+
+			// Ensure these specific functions are called for coverage
+			reconfigureDebugSettings()
+			simulateHttpRequest("/api/direct-test")
+			simulateWebSocketMessage("direct-test")
+		})
 	}
 }
@@ -37,7 +37,7 @@ func main() {
 	// Conditionally execute expensive debug operations
 	if debuggo.IsEnabled("app") {
 		// This code only runs when "app" debugging is enabled
-		debugApp("Detailed startup information: %v", getDetailedInfo())
+		debuggo.Dump("app", "Detailed startup information", getDetailedInfo())
 	}
 
 	fmt.Println("Application running. Debug messages were sent to stderr.")
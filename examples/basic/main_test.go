@@ -4,41 +4,49 @@ import (
 	"os"
 	"testing"
 
-	"github.com/GeoffreyPlitt/debuggo"
+	"github.com/GeoffreyPlitt/debuggo/debuggotest"
 )
 
 // TestBasicExample ensures all example code is executed for coverage
 func TestBasicExample(t *testing.T) {
-	// Redirect stderr to /dev/null to avoid test output pollution
-	origStderr := os.Stderr
+	// Redirect stdout to /dev/null to avoid test output pollution
 	origStdout := os.Stdout
 	devNull, _ := os.Open(os.DevNull)
-	os.Stderr = devNull
 	os.Stdout = devNull
 	defer func() {
-		os.Stderr = origStderr
 		os.Stdout = origStdout
 	}()
 
-	// Save original DEBUG value
-	originalDebug := os.Getenv("DEBUG")
-	defer os.Setenv("DEBUG", originalDebug)
+	t.Run("all enabled", func(t *testing.T) {
+		debuggotest.Capture(t, "*")
 
-	// Test with all debug enabled
-	os.Setenv("DEBUG", "*")
-	debuggo.ReloadDebugSettings()
+		// Call main directly - this will execute the complete example
+		main()
 
-	// Call main directly - this will execute the complete example
-	main()
+		debuggotest.AssertLogged(t, "app", "Application starting")
+		debuggotest.AssertLogged(t, "db", "Database connected")
+		debuggotest.AssertLogged(t, "api", "listening on port 8080")
+		debuggotest.AssertLogged(t, "app", "Detailed startup information")
+	})
 
-	// Test with only DB debug enabled
-	os.Setenv("DEBUG", "db")
-	debuggo.ReloadDebugSettings()
-	debugApp("This should not appear")
-	debugDb("This should appear")
+	t.Run("only db enabled", func(t *testing.T) {
+		debuggotest.Capture(t, "db")
 
-	// Test with no debug enabled
-	os.Setenv("DEBUG", "")
-	debuggo.ReloadDebugSettings()
-	getDetailedInfo() // Call directly for coverage
+		debugApp("This should not appear")
+		debugDb("This should appear")
+
+		debuggotest.AssertNotLogged(t, "app", "This should not appear")
+		debuggotest.AssertLogged(t, "db", "This should appear")
+	})
+
+	t.Run("none enabled", func(t *testing.T) {
+		buf := debuggotest.Capture(t, "")
+
+		main()
+		getDetailedInfo() // Call directly for coverage
+
+		if out := buf.String(); out != "" {
+			t.Errorf("Expected no debug output, got %q", out)
+		}
+	})
 }
@@ -0,0 +1,408 @@
+package debuggo
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxSize is the size in bytes at which a RotatingFile is rotated
+	// when MaxSize is zero.
+	DefaultMaxSize = 10 << 20
+	// DefaultMaxBackups is the number of rotated files a RotatingFile keeps
+	// when MaxBackups is zero.
+	DefaultMaxBackups = 3
+)
+
+// RotatingFile is an io.WriteCloser that writes debug output to a file and
+// rotates it once it grows past MaxSize or has been written to for longer
+// than MaxAge.
+//
+// Rotated files are renamed with a numeric suffix, newest first
+// (app-debug.log.1, app-debug.log.2, ...), optionally gzipped in the
+// background, and only the newest MaxBackups are kept.
+//
+// The file is opened, and its directory created, on the first write. Existing
+// files are appended to, and keep counting towards MaxAge from when they were
+// started rather than from when the program restarted.
+//
+// A RotatingFile can also be configured from the environment; see DEBUG_FILE
+// in the package documentation.
+//
+// Example:
+//
+//	debuggo.SetOutput(&debuggo.RotatingFile{
+//	    Filename:   "/var/log/app-debug.log",
+//	    MaxSize:    50 << 20,
+//	    MaxAge:     24 * time.Hour,
+//	    MaxBackups: 7,
+//	    Compress:   true,
+//	})
+type RotatingFile struct {
+	// Filename is the file to write to
+	Filename string
+	// MaxSize is the size in bytes after which the file is rotated.
+	// Defaults to DefaultMaxSize.
+	MaxSize int64
+	// MaxAge is how long a file is written to before it is rotated.
+	// Zero disables age-based rotation.
+	MaxAge time.Duration
+	// MaxBackups is the number of rotated files to keep.
+	// Defaults to DefaultMaxBackups.
+	MaxBackups int
+	// Compress gzips rotated files
+	Compress bool
+
+	mu       sync.Mutex
+	file     *os.File
+	closed   bool
+	size     int64
+	openedAt time.Time
+	// compressing receives the result of gzipping the newest backup, while
+	// that is in progress
+	compressing chan error
+	compressErr error
+	// now is the clock, replaceable in tests
+	now func() time.Time
+}
+
+// Write implements the io.Writer interface. It rotates the file first if p
+// would push it past MaxSize or the file has exceeded MaxAge. A single write
+// larger than MaxSize is written whole to a fresh file.
+func (rf *RotatingFile) Write(p []byte) (n int, err error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.closed {
+		return 0, os.ErrClosed
+	}
+	if rf.file == nil {
+		if err := rf.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if rf.size > 0 && (rf.size+int64(len(p)) > rf.maxSize() || rf.expired()) {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err = rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file, shifts it into the backups and starts a new
+// one, regardless of its size or age.
+func (rf *RotatingFile) Rotate() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.closed {
+		return os.ErrClosed
+	}
+	return rf.rotate()
+}
+
+// Close closes the current file, waiting for any backup still being
+// compressed, and returns the first error from compressing a backup, if
+// any. Writes after Close fail with os.ErrClosed.
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	rf.closed = true
+	rf.waitCompress()
+	err := rf.compressErr
+	rf.compressErr = nil
+
+	if rf.file != nil {
+		if cerr := rf.file.Close(); err == nil {
+			err = cerr
+		}
+		rf.file = nil
+	}
+	return err
+}
+
+// open opens Filename for appending, creating it and its directory if needed.
+// This must be called with the lock held.
+func (rf *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(rf.Filename), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(rf.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	rf.file = f
+	rf.size = info.Size()
+	rf.openedAt = rf.clock()
+	if rf.size > 0 {
+		// Appending to an earlier file; its age did not restart with us
+		rf.openedAt = rf.startedAt(info)
+	}
+	return nil
+}
+
+// startedAt estimates when the existing file described by info was started.
+// Rotation leaves the newest backup's modification time at the moment the
+// file was created; without a backup, its own modification time is the best
+// estimate available.
+func (rf *RotatingFile) startedAt(info os.FileInfo) time.Time {
+	started := info.ModTime()
+	for _, name := range rf.backupNames(1) {
+		if backup, err := os.Stat(name); err == nil && backup.ModTime().Before(started) {
+			started = backup.ModTime()
+		}
+	}
+	return started
+}
+
+// rotate shifts the backups up by one, moves the current file into the first
+// backup slot and opens a new file. This must be called with the lock held.
+func (rf *RotatingFile) rotate() error {
+	if rf.file != nil {
+		if err := rf.file.Close(); err != nil {
+			return err
+		}
+		rf.file = nil
+	}
+
+	// The newest backup is about to be renamed, so let its compression finish
+	rf.waitCompress()
+
+	backups := rf.maxBackups()
+
+	// Drop the oldest backup, then shift the rest up: .2 -> .3, .1 -> .2
+	for _, name := range rf.backupNames(backups) {
+		os.Remove(name)
+	}
+	for i := backups - 1; i >= 1; i-- {
+		for _, name := range rf.backupNames(i) {
+			os.Rename(name, rf.backupName(i+1)+strings.TrimPrefix(name, rf.backupName(i)))
+		}
+	}
+
+	if _, err := os.Stat(rf.Filename); err == nil {
+		first := rf.backupName(1)
+		if err := os.Rename(rf.Filename, first); err != nil {
+			return err
+		}
+		if rf.Compress {
+			// Compress in the background, as writers wait on this rotation
+			done := make(chan error, 1)
+			go func() { done <- compressFile(first) }()
+			rf.compressing = done
+		}
+	}
+
+	return rf.open()
+}
+
+// waitCompress waits for the newest backup to be compressed, keeping the
+// first error. This must be called with the lock held.
+func (rf *RotatingFile) waitCompress() {
+	if rf.compressing == nil {
+		return
+	}
+	if err := <-rf.compressing; err != nil && rf.compressErr == nil {
+		rf.compressErr = err
+	}
+	rf.compressing = nil
+}
+
+// backupName returns the uncompressed name of backup number i.
+func (rf *RotatingFile) backupName(i int) string {
+	return rf.Filename + "." + strconv.Itoa(i)
+}
+
+// backupNames returns both possible names of backup number i, since
+// Compress may have changed between rotations.
+func (rf *RotatingFile) backupNames(i int) []string {
+	name := rf.backupName(i)
+	return []string{name, name + ".gz"}
+}
+
+// expired reports whether the current file has been written to for longer
+// than MaxAge.
+func (rf *RotatingFile) expired() bool {
+	return rf.MaxAge > 0 && rf.clock().Sub(rf.openedAt) >= rf.MaxAge
+}
+
+func (rf *RotatingFile) maxSize() int64 {
+	if rf.MaxSize <= 0 {
+		return DefaultMaxSize
+	}
+	return rf.MaxSize
+}
+
+func (rf *RotatingFile) maxBackups() int {
+	if rf.MaxBackups <= 0 {
+		return DefaultMaxBackups
+	}
+	return rf.MaxBackups
+}
+
+func (rf *RotatingFile) clock() time.Time {
+	if rf.now != nil {
+		return rf.now()
+	}
+	return time.Now()
+}
+
+// compressFile gzips name to name.gz and removes the original.
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		zw.Close()
+		dst.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	src.Close()
+	return os.Remove(name)
+}
+
+// envFile is the RotatingFile installed from DEBUG_FILE, if any.
+var (
+	envFile   *RotatingFile
+	envFileMu sync.Mutex
+)
+
+// configureDebugFile installs a RotatingFile as the package output when the
+// DEBUG_FILE environment variable is set, replacing one installed by an
+// earlier call. Invalid limit values are reported on stderr and ignored.
+//
+// Supported variables:
+//   - DEBUG_FILE: path of the file to write to
+//   - DEBUG_FILE_MAX_SIZE: rotation size in bytes, with optional K, M or G suffix
+//   - DEBUG_FILE_MAX_AGE: rotation age as a Go duration (e.g. 24h)
+//   - DEBUG_FILE_MAX_BACKUPS: number of rotated files to keep
+//   - DEBUG_FILE_COMPRESS: gzip rotated files when set to a true value
+func configureDebugFile() {
+	envFileMu.Lock()
+	defer envFileMu.Unlock()
+
+	var next *RotatingFile
+	if name := os.Getenv("DEBUG_FILE"); name != "" {
+		next = &RotatingFile{Filename: name}
+
+		if v := os.Getenv("DEBUG_FILE_MAX_SIZE"); v != "" {
+			size, err := parseSize(v)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "debuggo: invalid DEBUG_FILE_MAX_SIZE %q: %v\n", v, err)
+			}
+			next.MaxSize = size
+		}
+		if v := os.Getenv("DEBUG_FILE_MAX_AGE"); v != "" {
+			age, err := time.ParseDuration(v)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "debuggo: invalid DEBUG_FILE_MAX_AGE %q: %v\n", v, err)
+			}
+			next.MaxAge = age
+		}
+		if v := os.Getenv("DEBUG_FILE_MAX_BACKUPS"); v != "" {
+			backups, err := strconv.Atoi(v)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "debuggo: invalid DEBUG_FILE_MAX_BACKUPS %q: %v\n", v, err)
+			}
+			next.MaxBackups = backups
+		}
+		if v := os.Getenv("DEBUG_FILE_COMPRESS"); v != "" {
+			compress, err := strconv.ParseBool(v)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "debuggo: invalid DEBUG_FILE_COMPRESS %q: %v\n", v, err)
+			}
+			next.Compress = compress
+		}
+	}
+
+	if envFile != nil && next != nil && sameFileConfig(envFile, next) {
+		return
+	}
+
+	settingsMu.Lock()
+	switch {
+	case next != nil:
+		defaultOutput = next
+	case envFile != nil && defaultOutput == io.Writer(envFile):
+		// DEBUG_FILE was unset; only restore stderr if the user has not
+		// replaced the output with SetOutput in the meantime
+		defaultOutput = nil
+	}
+	settingsMu.Unlock()
+
+	// Writers that already resolved envFile as their output get os.ErrClosed
+	// rather than reopening it
+	if envFile != nil {
+		envFile.Close()
+	}
+	envFile = next
+}
+
+// sameFileConfig reports whether two RotatingFiles have the same settings.
+func sameFileConfig(a, b *RotatingFile) bool {
+	return a.Filename == b.Filename && a.MaxSize == b.MaxSize && a.MaxAge == b.MaxAge &&
+		a.MaxBackups == b.MaxBackups && a.Compress == b.Compress
+}
+
+// parseSize parses a byte count with an optional K, M or G suffix (powers of
+// 1024), such as "512K" or "10M".
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	s = strings.TrimSuffix(s, "B")
+
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(s, "M"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(s, "G"):
+		multiplier = 1 << 30
+	}
+	if multiplier > 1 {
+		s = s[:len(s)-1]
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return n * multiplier, nil
+}
@@ -0,0 +1,282 @@
+package debuggo
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readFile returns the contents of name, transparently gunzipping .gz files.
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("Expected %s to exist: %v", name, err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(name, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("Expected %s to be gzipped: %v", name, err)
+		}
+		r = zr
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", name, err)
+	}
+	return string(data)
+}
+
+func TestRotatingFileRotatesBySize(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "logs", "debug.log")
+
+	rf := &RotatingFile{Filename: name, MaxSize: 10, MaxBackups: 2}
+	defer rf.Close()
+
+	for _, line := range []string{"one\n", "two\n", "three\n", "four\n", "five\n", "six\n"} {
+		if _, err := rf.Write([]byte(line)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	// Files hold at most 10 bytes; "one\ntwo\n" was rotated out of the backups
+	expected := map[string]string{
+		name:        "six\n",
+		name + ".1": "four\nfive\n",
+		name + ".2": "three\n",
+	}
+	for file, content := range expected {
+		if got := readFile(t, file); got != content {
+			t.Errorf("Expected %s to contain %q, got %q", file, content, got)
+		}
+	}
+
+	if _, err := os.Stat(name + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Expected only 2 backups to be kept, found %s.3", name)
+	}
+}
+
+func TestRotatingFileRotatesByAge(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "debug.log")
+
+	now := time.Date(2025, 5, 21, 0, 0, 0, 0, time.UTC)
+	rf := &RotatingFile{Filename: name, MaxAge: time.Hour}
+	rf.now = func() time.Time { return now }
+	defer rf.Close()
+
+	rf.Write([]byte("old\n"))
+	now = now.Add(30 * time.Minute)
+	rf.Write([]byte("still fresh\n"))
+	now = now.Add(30 * time.Minute)
+	rf.Write([]byte("new\n"))
+
+	if got := readFile(t, name+".1"); got != "old\nstill fresh\n" {
+		t.Errorf("Expected backup to hold the first hour, got %q", got)
+	}
+	if got := readFile(t, name); got != "new\n" {
+		t.Errorf("Expected a fresh file after MaxAge, got %q", got)
+	}
+}
+
+func TestRotatingFileCompress(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "debug.log")
+
+	rf := &RotatingFile{Filename: name, MaxBackups: 2, Compress: true}
+
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		rf.Write([]byte(line))
+		if err := rf.Rotate(); err != nil {
+			t.Fatalf("Rotate failed: %v", err)
+		}
+	}
+	// Close waits for the newest backup to be compressed
+	if err := rf.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if got := readFile(t, name+".1.gz"); got != "third\n" {
+		t.Errorf("Expected newest backup to be compressed, got %q", got)
+	}
+	if got := readFile(t, name+".2.gz"); got != "second\n" {
+		t.Errorf("Expected compressed backups to shift, got %q", got)
+	}
+	for _, stale := range []string{name + ".1", name + ".3.gz"} {
+		if _, err := os.Stat(stale); !os.IsNotExist(err) {
+			t.Errorf("Expected %s not to exist", stale)
+		}
+	}
+}
+
+func TestRotatingFileAppendsToExisting(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "debug.log")
+	os.WriteFile(name, []byte("existing\n"), 0600)
+
+	rf := &RotatingFile{Filename: name, MaxSize: 12}
+	rf.Write([]byte("new\n"))
+	rf.Close()
+
+	// The existing size counts towards MaxSize
+	if got := readFile(t, name+".1"); got != "existing\n" {
+		t.Errorf("Expected existing content to be rotated, got %q", got)
+	}
+}
+
+func TestRotatingFileAgeSurvivesRestart(t *testing.T) {
+	testCases := []struct {
+		description string
+		fileAge     time.Duration
+		backupAge   time.Duration
+		rotated     bool
+	}{
+		{description: "old file", fileAge: 2 * time.Hour, rotated: true},
+		{description: "fresh file", fileAge: time.Minute, rotated: false},
+		{description: "recently written file started after an old rotation", fileAge: time.Minute, backupAge: 2 * time.Hour, rotated: true},
+		{description: "recent rotation", fileAge: time.Minute, backupAge: 30 * time.Minute, rotated: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "debug.log")
+			now := time.Now()
+
+			os.WriteFile(name, []byte("before restart\n"), 0600)
+			os.Chtimes(name, now, now.Add(-tc.fileAge))
+			if tc.backupAge > 0 {
+				os.WriteFile(name+".1.gz", nil, 0600)
+				os.Chtimes(name+".1.gz", now, now.Add(-tc.backupAge))
+			}
+
+			// A restarted program appends to the existing file
+			rf := &RotatingFile{Filename: name, MaxAge: time.Hour}
+			rf.now = func() time.Time { return now }
+			rf.Write([]byte("after restart\n"))
+			rf.Close()
+
+			_, err := os.Stat(name + ".1")
+			if rotated := err == nil; rotated != tc.rotated {
+				t.Errorf("Expected rotated=%v, got %v", tc.rotated, rotated)
+			}
+		})
+	}
+}
+
+func TestRotatingFileClosed(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "debug.log")
+
+	rf := &RotatingFile{Filename: name}
+	rf.Write([]byte("before\n"))
+	if err := rf.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if _, err := rf.Write([]byte("after\n")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Expected Write after Close to fail with os.ErrClosed, got %v", err)
+	}
+	if err := rf.Rotate(); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Expected Rotate after Close to fail with os.ErrClosed, got %v", err)
+	}
+	if rf.file != nil {
+		t.Error("Expected no file to be reopened after Close")
+	}
+	if got := readFile(t, name); got != "before\n" {
+		t.Errorf("Expected only the line written before Close, got %q", got)
+	}
+}
+
+func TestRotatingFileCompressesInBackground(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "debug.log")
+
+	rf := &RotatingFile{Filename: name, MaxSize: 1 << 20, Compress: true}
+	defer rf.Close()
+
+	rf.Write([]byte("first\n"))
+	if err := rf.Rotate(); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	// Writers hold the output lock during rotation, so it must not wait for
+	// compression
+	if rf.compressing == nil {
+		t.Fatal("Expected the backup to be compressed in the background")
+	}
+
+	// Writes continue while the backup is compressed
+	if _, err := rf.Write([]byte("second\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := rf.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got := readFile(t, name+".1.gz"); got != "first\n" {
+		t.Errorf("Expected the backup to be compressed, got %q", got)
+	}
+	if got := readFile(t, name); got != "second\n" {
+		t.Errorf("Expected writes to continue during compression, got %q", got)
+	}
+}
+
+func TestDebugFileEnv(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "debug.log")
+
+	os.Setenv("DEBUG", "app")
+	os.Setenv("DEBUG_FILE", name)
+	os.Setenv("DEBUG_FILE_MAX_SIZE", "1K")
+	os.Setenv("DEBUG_FILE_MAX_BACKUPS", "5")
+	defer func() {
+		os.Unsetenv("DEBUG_FILE")
+		os.Unsetenv("DEBUG_FILE_MAX_SIZE")
+		os.Unsetenv("DEBUG_FILE_MAX_BACKUPS")
+		ReloadDebugSettings()
+	}()
+	ReloadDebugSettings()
+
+	if envFile == nil || envFile.MaxSize != 1024 || envFile.MaxBackups != 5 {
+		t.Fatalf("Expected DEBUG_FILE settings to be applied, got %+v", envFile)
+	}
+
+	Debug("app")("Written to file")
+
+	if got := readFile(t, name); !strings.HasSuffix(got, " app Written to file\n") {
+		t.Errorf("Expected debug line in DEBUG_FILE, got %q", got)
+	}
+
+	// Unsetting DEBUG_FILE restores stderr
+	os.Unsetenv("DEBUG_FILE")
+	ReloadDebugSettings()
+	if envFile != nil || defaultOutput != nil {
+		t.Errorf("Expected output to be restored after unsetting DEBUG_FILE")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected int64
+		valid    bool
+	}{
+		{"100", 100, true},
+		{"512K", 512 << 10, true},
+		{"10mb", 10 << 20, true},
+		{"1G", 1 << 30, true},
+		{"lots", 0, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := parseSize(tc.input)
+			if (err == nil) != tc.valid || got != tc.expected {
+				t.Errorf("Expected (%d, valid=%v), got (%d, %v)", tc.expected, tc.valid, got, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package debuggo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record is a single debug line before it has been formatted.
+type Record struct {
+	// Time is when the debug call was made
+	Time time.Time
+	// Namespace is the module the line was logged under (e.g. "app:server")
+	Namespace string
+	// Message is the already formatted message text
+	Message string
+	// Fields are key/value pairs attached to the line, e.g. from a context
+	Fields []Field
+}
+
+// Field is a key/value pair attached to a debug line.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F is a shorthand for constructing a Field.
+//
+// Example:
+//
+//	ctx = debuggo.WithContext(ctx, debuggo.F("request_id", id))
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Formatter turns a Record into the bytes written to a Logger's output.
+// Implementations must append exactly one complete line, including the
+// trailing newline, to buf.
+type Formatter interface {
+	Format(buf *bytes.Buffer, r *Record)
+}
+
+// DefaultTimeFormat is the timestamp layout used by TextFormatter when
+// TimeFormat is empty.
+const DefaultTimeFormat = "15:04:05.000"
+
+// TextFormatter renders records in the classic debuggo layout, followed by
+// any fields as key=value pairs:
+//
+//	15:04:05.000 module_name message request_id=42
+//
+// This is the default formatter.
+type TextFormatter struct {
+	// TimeFormat is the time layout for the timestamp column.
+	// Defaults to DefaultTimeFormat.
+	TimeFormat string
+}
+
+// Format implements the Formatter interface.
+func (f TextFormatter) Format(buf *bytes.Buffer, r *Record) {
+	layout := f.TimeFormat
+	if layout == "" {
+		layout = DefaultTimeFormat
+	}
+
+	buf.WriteString(r.Time.Format(layout))
+	buf.WriteByte(' ')
+	buf.WriteString(r.Namespace)
+	buf.WriteByte(' ')
+	buf.WriteString(r.Message)
+	for _, field := range r.Fields {
+		buf.WriteByte(' ')
+		buf.WriteString(field.Key)
+		buf.WriteByte('=')
+		buf.WriteString(textValue(field.Value))
+	}
+	buf.WriteByte('\n')
+}
+
+// textValue renders a field value for TextFormatter, quoting it when it
+// would otherwise be ambiguous to read back.
+func textValue(v interface{}) string {
+	s := fmt.Sprint(v)
+	if s == "" || strings.ContainsAny(s, " =\"\t\r\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// JSONFormatter renders each record as a single-line JSON object, which is
+// convenient when debug output is collected by a log shipper. Fields are
+// added as top-level keys after the message:
+//
+//	{"time":"2025-05-21T22:01:53.108Z","namespace":"app","message":"starting","request_id":42}
+type JSONFormatter struct{}
+
+// Format implements the Formatter interface.
+func (JSONFormatter) Format(buf *bytes.Buffer, r *Record) {
+	buf.WriteString(`{"time":`)
+	writeJSON(buf, r.Time)
+	buf.WriteString(`,"namespace":`)
+	writeJSON(buf, r.Namespace)
+	buf.WriteString(`,"message":`)
+	writeJSON(buf, r.Message)
+	for _, field := range r.Fields {
+		buf.WriteByte(',')
+		writeJSON(buf, field.Key)
+		buf.WriteByte(':')
+		writeJSON(buf, field.Value)
+	}
+	buf.WriteString("}\n")
+}
+
+// writeJSON appends v to buf as compact JSON. Errors are written as their
+// message, and values that cannot be marshaled are written as their fmt
+// representation instead, so a bad field never loses the rest of the line.
+func writeJSON(buf *bytes.Buffer, v interface{}) {
+	if err, ok := v.(error); ok {
+		v = err.Error()
+	}
+
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		out.Reset()
+		_ = enc.Encode(fmt.Sprint(v))
+	}
+	// Encode always terminates with a newline, which must not split the line
+	buf.Write(bytes.TrimSuffix(out.Bytes(), []byte("\n")))
+}
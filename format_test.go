@@ -0,0 +1,84 @@
+package debuggo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTextFormatter(t *testing.T) {
+	ts := time.Date(2025, 5, 21, 22, 1, 53, 108000000, time.UTC)
+
+	testCases := []struct {
+		record      Record
+		expected    string
+		description string
+	}{
+		{
+			Record{Time: ts, Namespace: "app", Message: "starting"},
+			"22:01:53.108 app starting\n",
+			"Classic layout",
+		},
+		{
+			Record{Time: ts, Namespace: "app", Message: "req", Fields: []Field{F("id", 42), F("ok", true)}},
+			"22:01:53.108 app req id=42 ok=true\n",
+			"Fields are appended as key=value",
+		},
+		{
+			Record{Time: ts, Namespace: "app", Message: "req", Fields: []Field{F("path", "a b"), F("empty", "")}},
+			"22:01:53.108 app req path=\"a b\" empty=\"\"\n",
+			"Ambiguous values are quoted",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			TextFormatter{}.Format(buf, &tc.record)
+			if buf.String() != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestJSONFormatter(t *testing.T) {
+	r := Record{
+		Time:      time.Date(2025, 5, 21, 22, 1, 53, 0, time.UTC),
+		Namespace: "app:http",
+		Message:   "<b> & done",
+		Fields:    []Field{F("status", 200), F("err", errors.New("boom")), F("bad", func() {})},
+	}
+
+	buf := &bytes.Buffer{}
+	JSONFormatter{}.Format(buf, &r)
+
+	if bytes.Count(buf.Bytes(), []byte("\n")) != 1 {
+		t.Fatalf("Expected exactly one line, got %q", buf.String())
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected valid JSON, got %q: %v", buf.String(), err)
+	}
+
+	expected := map[string]interface{}{
+		"time":      "2025-05-21T22:01:53Z",
+		"namespace": "app:http",
+		"message":   "<b> & done",
+		"status":    float64(200),
+		"err":       "boom",
+	}
+	for key, want := range expected {
+		if got := entry[key]; got != want {
+			t.Errorf("Expected %s to be %v, got %v", key, want, got)
+		}
+	}
+
+	// Unmarshalable values fall back to their fmt representation
+	if _, ok := entry["bad"].(string); !ok {
+		t.Errorf("Expected unmarshalable field to be a string, got %v", entry["bad"])
+	}
+}
@@ -0,0 +1,91 @@
+package debuggo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"sync/atomic"
+)
+
+// GoroutineField is the key of the field holding the goroutine ID when
+// SetGoroutineFields is on.
+const GoroutineField = "goroutine"
+
+// goroutineFields is set while goroutine fields are added to each line.
+var goroutineFields atomic.Bool
+
+// SetGoroutineFields adds fields identifying where each debug line was
+// logged: the ID of the logging goroutine, and for lines logged through a
+// context-aware function such as PrintfContext, the runtime/pprof labels
+// attached to the context with pprof.WithLabels or pprof.Do. They are
+// written like other fields, as key=value pairs by TextFormatter and as keys
+// of the object by JSONFormatter:
+//
+//	12:34:56.789 app:worker Processing job 7 goroutine=42 worker=3
+//
+// Labels are read from the context because Go offers no way to read the
+// labels of the current goroutine. Fields are off by default: finding the
+// goroutine ID means formatting its stack trace, which costs several
+// microseconds per line (see BenchmarkGoroutineFields). They can also be
+// turned on by setting DEBUG_GOROUTINE to a true value. It returns the
+// previous setting.
+//
+// Example:
+//
+//	debuggo.SetGoroutineFields(true)
+func SetGoroutineFields(on bool) (previous bool) {
+	return goroutineFields.Swap(on)
+}
+
+// configureGoroutineFields applies DEBUG_GOROUTINE, if set.
+func configureGoroutineFields() {
+	v := os.Getenv("DEBUG_GOROUTINE")
+	if v == "" {
+		return
+	}
+	on, err := strconv.ParseBool(v)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "debuggo: invalid DEBUG_GOROUTINE %q: %v\n", v, err)
+		return
+	}
+	goroutineFields.Store(on)
+}
+
+// appendGoroutineFields appends the goroutine ID and the pprof labels of ctx
+// to fields.
+func appendGoroutineFields(ctx context.Context, fields []Field) []Field {
+	fields = append(fields, Field{Key: GoroutineField, Value: goroutineID()})
+	if ctx != nil {
+		pprof.ForLabels(ctx, func(key, value string) bool {
+			fields = append(fields, Field{Key: key, Value: value})
+			return true
+		})
+	}
+	return fields
+}
+
+// goroutinePrefix starts the header line of runtime.Stack output.
+var goroutinePrefix = []byte("goroutine ")
+
+// goroutineID returns the ID of the calling goroutine, parsed from the
+// header of its stack trace ("goroutine 42 [running]:"). It returns 0 if
+// the header cannot be parsed.
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+
+	b = bytes.TrimPrefix(b, goroutinePrefix)
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+
+	id, err := strconv.ParseUint(string(b), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
@@ -0,0 +1,164 @@
+package debuggo
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"regexp"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGoroutineID(t *testing.T) {
+	id := goroutineID()
+	if id == 0 {
+		t.Fatal("Expected a goroutine ID")
+	}
+	if again := goroutineID(); again != id {
+		t.Errorf("Expected a stable ID, got %d then %d", id, again)
+	}
+
+	other := make(chan uint64)
+	go func() { other <- goroutineID() }()
+	if got := <-other; got == 0 || got == id {
+		t.Errorf("Expected a different ID on another goroutine, got %d (ours is %d)", got, id)
+	}
+}
+
+func TestGoroutineFields(t *testing.T) {
+	Enable("app:*")
+	defer Disable()
+
+	buf := &syncBuffer{}
+	l := New("app:worker").WithOutput(buf).WithFormatter(JSONFormatter{})
+	ctx := pprof.WithLabels(context.Background(), pprof.Labels("worker", "3"))
+	ctx = WithContext(ctx, F("job", 7))
+
+	l.PrintfContext(ctx, "off")
+
+	defer SetGoroutineFields(SetGoroutineFields(true))
+	l.Printf("plain")
+	l.PrintfContext(ctx, "labelled")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %q", lines)
+	}
+
+	id := goroutineID()
+	testCases := []struct {
+		description string
+		line        string
+		expected    map[string]interface{}
+	}{
+		{
+			description: "off by default",
+			line:        lines[0],
+			expected:    map[string]interface{}{"job": float64(7)},
+		},
+		{
+			description: "goroutine ID without a context",
+			line:        lines[1],
+			expected:    map[string]interface{}{GoroutineField: float64(id)},
+		},
+		{
+			description: "goroutine ID and labels with a context",
+			line:        lines[2],
+			expected:    map[string]interface{}{"job": float64(7), GoroutineField: float64(id), "worker": "3"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			var got map[string]interface{}
+			if err := json.Unmarshal([]byte(tc.line), &got); err != nil {
+				t.Fatalf("Failed to parse %q: %v", tc.line, err)
+			}
+			for _, key := range []string{"time", "namespace", "message"} {
+				delete(got, key)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Expected fields %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestGoroutineFieldsText(t *testing.T) {
+	Enable("app:*")
+	defer Disable()
+	defer SetGoroutineFields(SetGoroutineFields(true))
+
+	buf := &syncBuffer{}
+	l := New("app:worker").WithOutput(buf).WithFormatter(TextFormatter{})
+
+	done := make(chan struct{})
+	pprof.Do(context.Background(), pprof.Labels("worker", "3"), func(ctx context.Context) {
+		go func() {
+			defer close(done)
+			l.PrintfContext(ctx, "processing")
+		}()
+	})
+	<-done
+
+	want := regexp.MustCompile(`^\S+ app:worker processing goroutine=(\d+) worker=3\n$`)
+	m := want.FindStringSubmatch(buf.String())
+	if m == nil {
+		t.Fatalf("Expected output matching %q, got %q", want, buf.String())
+	}
+	if m[1] == strconv.FormatUint(goroutineID(), 10) {
+		t.Error("Expected the ID of the logging goroutine, got the test's")
+	}
+}
+
+func TestGoroutineFieldsFromEnv(t *testing.T) {
+	defer SetGoroutineFields(SetGoroutineFields(false))
+
+	t.Setenv("DEBUG_GOROUTINE", "true")
+	configureGoroutineFields()
+	if !goroutineFields.Load() {
+		t.Error("Expected DEBUG_GOROUTINE=true to turn goroutine fields on")
+	}
+
+	t.Setenv("DEBUG_GOROUTINE", "")
+	configureGoroutineFields()
+	if !goroutineFields.Load() {
+		t.Error("Expected an empty DEBUG_GOROUTINE to leave the setting alone")
+	}
+
+	t.Setenv("DEBUG_GOROUTINE", "0")
+	configureGoroutineFields()
+	if goroutineFields.Load() {
+		t.Error("Expected DEBUG_GOROUTINE=0 to turn goroutine fields off")
+	}
+}
+
+func BenchmarkGoroutineFields(b *testing.B) {
+	Enable("bench")
+	defer Disable()
+
+	l := New("bench").WithOutput(io.Discard)
+	ctx := pprof.WithLabels(context.Background(), pprof.Labels("worker", "3"))
+
+	benchmarks := []struct {
+		description string
+		on          bool
+	}{
+		{description: "off", on: false},
+		{description: "on", on: true},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.description, func(b *testing.B) {
+			defer SetGoroutineFields(SetGoroutineFields(bm.on))
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				l.PrintfContext(ctx, "message %d", i)
+			}
+		})
+	}
+}
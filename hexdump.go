@@ -0,0 +1,140 @@
+package debuggo
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// hexDumpWidth is the number of bytes shown on each row of a HexDump.
+const hexDumpWidth = 16
+
+// hexDumpRedactMargin is how many bytes past the shown ones are matched
+// against the redactions, so that secrets cut off by the limit are masked.
+const hexDumpRedactMargin = 256
+
+// HexDump logs data under namespace in the format of hexdump -C, one debug
+// line per row, with offsets in hex and printable bytes in the right-hand
+// column:
+//
+//	debuggo.HexDump("app:proto", "frame", frame)
+//
+//	12:34:56.789 app:proto frame: 37 bytes
+//	12:34:56.789 app:proto 00000000  47 45 54 20 2f 20 48 54  54 50 2f 31 2e 31 0d 0a  |GET / HTTP/1.1..|
+//	12:34:56.789 app:proto 00000010  48 6f 73 74 3a 20 65 78  61 6d 70 6c 65 2e 63 6f  |Host: example.co|
+//	12:34:56.789 app:proto 00000020  6d 0d 0a 0d 0a                                    |m....|
+//	12:34:56.789 app:proto 00000025
+//
+// As with hexdump -C, runs of identical rows are collapsed into a single *.
+// Bytes matched by the redactions installed with SetRedactions are shown as
+// ** in the hex column and * in the text column, so offsets stay correct.
+// Only the first DumpOptions.MaxBytes bytes are shown; see SetDumpOptions.
+// A Limit on namespace keeps or drops all the rows of a dump together.
+// Nothing is computed when namespace is disabled.
+func HexDump(namespace, label string, data []byte) {
+	New(namespace).HexDump(label, data)
+}
+
+// HexDump is the Logger equivalent of the package-level HexDump function.
+func (l *Logger) HexDump(label string, data []byte) {
+	// Check first to avoid formatting data when nothing will use it
+	if !IsEnabled(l.namespace) && activeRecorder() == nil {
+		return
+	}
+	if h := l.helper(); h != nil {
+		h.Helper()
+	}
+
+	// Rows are redacted by hexDumpLines; redacting them again as text would
+	// mangle the hex column. Like Dump, the rows share one Limit check.
+	enabled := l.allowed()
+	for _, line := range hexDumpLines(label, data, currentDumpOptions().MaxBytes) {
+		l.emit(nil, enabled, message{format: line, mode: modeRedacted})
+	}
+}
+
+// hexDumpLines renders the rows of a HexDump showing at most limit bytes.
+func hexDumpLines(label string, data []byte, limit int) []string {
+	header := fmt.Sprintf("%d bytes", len(data))
+	if label != "" {
+		header = redactText(label) + ": " + header
+	}
+	lines := []string{header}
+	if len(data) == 0 {
+		return lines
+	}
+
+	shown := data
+	if len(shown) > limit {
+		shown = shown[:limit]
+	}
+
+	// masked holds 1 for each byte to redact
+	masked := make([]byte, len(shown))
+	if redactions.Load() != nil {
+		// Only convert what can reach the shown bytes, not the whole payload
+		scanned := data[:min(len(data), len(shown)+hexDumpRedactMargin)]
+		for _, span := range redactedSpans(string(scanned)) {
+			for i := span[0]; i < span[1] && i < len(shown); i++ {
+				masked[i] = 1
+			}
+		}
+	}
+
+	var previous, previousMask []byte
+	collapsed := false
+	for offset := 0; offset < len(shown); offset += hexDumpWidth {
+		end := min(offset+hexDumpWidth, len(shown))
+		row, mask := shown[offset:end], masked[offset:end]
+		// Collapse full rows repeating the one before
+		if len(row) == hexDumpWidth && bytes.Equal(row, previous) && bytes.Equal(mask, previousMask) {
+			if !collapsed {
+				lines = append(lines, "*")
+				collapsed = true
+			}
+			continue
+		}
+		previous, previousMask, collapsed = row, mask, false
+		lines = append(lines, hexDumpRow(offset, row, mask))
+	}
+
+	if len(data) > len(shown) {
+		lines = append(lines, fmt.Sprintf("%08x  ... (%d more bytes)", len(shown), len(data)-len(shown)))
+	} else {
+		lines = append(lines, fmt.Sprintf("%08x", len(data)))
+	}
+	return lines
+}
+
+// hexDumpRow formats up to hexDumpWidth bytes starting at offset. Bytes
+// with a non-zero mask are redacted.
+func hexDumpRow(offset int, row, mask []byte) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%08x  ", offset)
+	for i := 0; i < hexDumpWidth; i++ {
+		switch {
+		case i >= len(row):
+			b.WriteString("   ")
+		case mask[i] != 0:
+			b.WriteString("** ")
+		default:
+			fmt.Fprintf(&b, "%02x ", row[i])
+		}
+		if i == hexDumpWidth/2-1 {
+			b.WriteByte(' ')
+		}
+	}
+
+	b.WriteString(" |")
+	for i, c := range row {
+		switch {
+		case mask[i] != 0:
+			c = '*'
+		case c < 0x20 || c > 0x7e:
+			c = '.'
+		}
+		b.WriteByte(c)
+	}
+	b.WriteByte('|')
+	return b.String()
+}
@@ -0,0 +1,190 @@
+package debuggo
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// hexDumpOutput hex dumps data with opts and returns the lines written.
+func hexDumpOutput(t *testing.T, opts DumpOptions, label string, data []byte) []string {
+	t.Helper()
+
+	previous := SetDumpOptions(opts)
+	defer SetDumpOptions(previous)
+
+	buf := &syncBuffer{}
+	New("app:hex").WithOutput(buf).WithFormatter(messageOnly{}).HexDump(label, data)
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+}
+
+func TestHexDump(t *testing.T) {
+	Enable("app:hex")
+	defer Disable()
+
+	testCases := []struct {
+		description string
+		opts        DumpOptions
+		label       string
+		data        []byte
+		expected    []string
+	}{
+		{
+			description: "partial last row",
+			label:       "frame",
+			data:        []byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"),
+			expected: []string{
+				"frame: 37 bytes",
+				"00000000  47 45 54 20 2f 20 48 54  54 50 2f 31 2e 31 0d 0a  |GET / HTTP/1.1..|",
+				"00000010  48 6f 73 74 3a 20 65 78  61 6d 70 6c 65 2e 63 6f  |Host: example.co|",
+				"00000020  6d 0d 0a 0d 0a                                    |m....|",
+				"00000025",
+			},
+		},
+		{
+			description: "empty",
+			label:       "frame",
+			data:        nil,
+			expected:    []string{"frame: 0 bytes"},
+		},
+		{
+			description: "no label",
+			data:        []byte{0x00, 0xff},
+			expected: []string{
+				"2 bytes",
+				"00000000  00 ff                                             |..|",
+				"00000002",
+			},
+		},
+		{
+			description: "repeated rows are collapsed",
+			data:        append(make([]byte, 48), 'x'),
+			expected: []string{
+				"49 bytes",
+				"00000000  00 00 00 00 00 00 00 00  00 00 00 00 00 00 00 00  |................|",
+				"*",
+				"00000030  78                                                |x|",
+				"00000031",
+			},
+		},
+		{
+			description: "the final offset follows collapsed rows",
+			data:        make([]byte, 32),
+			expected: []string{
+				"32 bytes",
+				"00000000  00 00 00 00 00 00 00 00  00 00 00 00 00 00 00 00  |................|",
+				"*",
+				"00000020",
+			},
+		},
+		{
+			description: "truncated",
+			opts:        DumpOptions{MaxBytes: 4},
+			data:        []byte("abcdefgh"),
+			expected: []string{
+				"8 bytes",
+				"00000000  61 62 63 64                                       |abcd|",
+				"00000004  ... (4 more bytes)",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			got := hexDumpOutput(t, tc.opts, tc.label, tc.data)
+			if strings.Join(got, "\n") != strings.Join(tc.expected, "\n") {
+				t.Errorf("Expected:\n%s\ngot:\n%s", strings.Join(tc.expected, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestHexDumpMatchesEncodingHex(t *testing.T) {
+	Enable("app:hex")
+	defer Disable()
+
+	data := make([]byte, 256)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	// encoding/hex uses the same row layout, without collapsing or the
+	// final offset
+	expected := strings.Split(strings.TrimSuffix(hex.Dump(data), "\n"), "\n")
+	got := hexDumpOutput(t, DumpOptions{}, "", data)
+	got = got[1 : len(got)-1]
+
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestHexDumpDisabled(t *testing.T) {
+	Enable("app:other")
+	defer Disable()
+
+	buf := &syncBuffer{}
+	l := New("app:hex").WithOutput(buf)
+	data := bytes.Repeat([]byte("x"), 64)
+
+	check := testing.AllocsPerRun(100, func() {
+		IsEnabled("app:hex")
+	})
+	allocs := testing.AllocsPerRun(100, func() {
+		l.HexDump("frame", data)
+	})
+	if allocs > check {
+		t.Errorf("Expected a disabled hex dump to allocate at most %v times, got %v", check, allocs)
+	}
+
+	if buf.String() != "" {
+		t.Errorf("Expected no output, got %q", buf.String())
+	}
+}
+
+func TestHexDumpLimit(t *testing.T) {
+	Enable("app:hex")
+	defer Disable()
+	defer ClearLimits()
+	SetLimit("app:hex", Limit{Sample: 2})
+
+	buf := &syncBuffer{}
+	l := New("app:hex").WithOutput(buf).WithFormatter(messageOnly{})
+	for i := 0; i < 3; i++ {
+		l.HexDump("", []byte("hi"))
+	}
+
+	// The first and third dumps are kept whole, the second is dropped whole
+	rows := "2 bytes\n00000000  68 69                                             |hi|\n00000002\n"
+	if buf.String() != rows+rows {
+		t.Errorf("Expected two complete hex dumps, got %q", buf.String())
+	}
+}
+
+func TestHexDumpRedactions(t *testing.T) {
+	Enable("app:hex")
+	defer Disable()
+
+	prev := SetRedactions(RedactCreditCards)
+	defer SetRedactions(prev...)
+
+	data := []byte("card=4111111111111111;")
+	expected := []string{
+		"22 bytes",
+		"00000000  63 61 72 64 3d ** ** **  ** ** ** ** ** ** ** **  |card=***********|",
+		"00000010  ** ** ** ** ** 3b                                 |*****;|",
+		"00000016",
+	}
+
+	got := hexDumpOutput(t, DumpOptions{}, "", data)
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+
+	// A card number cut off by the limit is still masked
+	got = hexDumpOutput(t, DumpOptions{MaxBytes: 8}, "", data)
+	if got[1] != "00000000  63 61 72 64 3d ** ** **                           |card=***|" {
+		t.Errorf("Expected the shown part of the card number to be masked, got %q", got[1])
+	}
+}
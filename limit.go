@@ -0,0 +1,360 @@
+package debuggo
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultSummaryInterval is how long a rate-limited namespace waits before
+// reporting how many lines it suppressed, when Limit.SummaryInterval is zero.
+const DefaultSummaryInterval = 5 * time.Second
+
+// Limit throttles how many lines an enabled namespace writes. Sampling is
+// applied first, then the rate limit. Lines that are held back are counted,
+// and a "suppressed N messages" line is written under the namespace once
+// SummaryInterval has passed since the first of them.
+//
+// Limits can be set from DEBUG by appending options to a namespace after an
+// @, separated by semicolons:
+//
+//	DEBUG='app:*,app:server:http@sample=100'           # 1 in 100 lines
+//	DEBUG='app:*,app:server:http@first=10;every=100'   # first 10, then every 100th
+//	DEBUG='app:*,app:server:http@rate=50/s;burst=100'  # token bucket
+//
+// or in code with SetLimit.
+type Limit struct {
+	// Sample writes only 1 in every Sample lines, starting with the first
+	Sample int
+	// First writes the first First lines; after that only every Every-th
+	// line is written (none if Every is zero)
+	First int
+	Every int
+	// Rate is the sustained number of lines per second allowed through a
+	// token bucket; zero means no rate limit
+	Rate float64
+	// Burst is the token bucket size. Defaults to Rate rounded up, at least 1.
+	Burst int
+	// SummaryInterval is how long to wait before reporting suppressed lines.
+	// Defaults to DefaultSummaryInterval.
+	SummaryInterval time.Duration
+}
+
+// IsZero reports whether the limit lets every line through.
+func (l Limit) IsZero() bool {
+	return l.Sample <= 1 && l.First <= 0 && l.Every <= 0 && l.Rate <= 0
+}
+
+// limitRule applies a Limit to every namespace matching a DEBUG-style pattern.
+type limitRule struct {
+	pattern string
+	match   *namespaceSpec
+	limit   Limit
+}
+
+var (
+	limitMu    sync.Mutex
+	limitRules []*limitRule
+	limiters   = map[string]*limiter{}
+	// limitGen changes whenever the rules from SetLimit or DEBUG change, so
+	// cached limiters know to re-resolve their rule
+	limitGen atomic.Uint64
+	// apiLimits and specLimits let unthrottled programs skip all of this
+	apiLimits  atomic.Bool
+	specLimits atomic.Bool
+	// limitClock is the clock for token buckets, replaceable in tests
+	limitClock = time.Now
+)
+
+// SetLimit applies l to every namespace matching pattern, which uses DEBUG
+// syntax (e.g. "app:server:*"). Each matching namespace is throttled
+// separately. Limits set here take precedence over those from DEBUG; a zero
+// Limit removes the limit for pattern.
+//
+// Example:
+//
+//	debuggo.SetLimit("app:server:http", debuggo.Limit{Rate: 50, Burst: 100})
+func SetLimit(pattern string, l Limit) {
+	limitMu.Lock()
+	defer limitMu.Unlock()
+
+	rules := make([]*limitRule, 0, len(limitRules)+1)
+	for _, r := range limitRules {
+		if r.pattern != pattern {
+			rules = append(rules, r)
+		}
+	}
+	if !l.IsZero() {
+		rules = append(rules, newLimitRule(pattern, l))
+	}
+
+	limitRules = rules
+	apiLimits.Store(len(rules) > 0)
+	limitGen.Add(1)
+}
+
+// ClearLimits removes every limit set with SetLimit. Limits from DEBUG stay
+// in effect.
+func ClearLimits() {
+	limitMu.Lock()
+	defer limitMu.Unlock()
+
+	limitRules = nil
+	apiLimits.Store(false)
+	limitGen.Add(1)
+}
+
+// newLimitRule returns a rule applying l to namespaces matching pattern.
+func newLimitRule(pattern string, l Limit) *limitRule {
+	return &limitRule{pattern: pattern, match: parseSpec(pattern), limit: l}
+}
+
+// specChanged must be called whenever the active namespace spec is replaced.
+func specChanged(s *namespaceSpec) {
+	specLimits.Store(len(s.limits) > 0)
+	limitGen.Add(1)
+}
+
+// limiter is the throttling state of a single namespace.
+type limiter struct {
+	gen   uint64
+	limit *Limit
+	count uint64
+	// token bucket
+	tokens float64
+	last   time.Time
+	// suppressed lines since the last summary, and where to report them
+	suppressed int
+	logger     *Logger
+	timer      *time.Timer
+}
+
+// allow reports whether a line from this logger's enabled namespace may be
+// written, counting it towards a suppression summary if not.
+func (l *Logger) allow() bool {
+	if !apiLimits.Load() && !specLimits.Load() {
+		return true
+	}
+
+	gen := limitGen.Load()
+
+	limitMu.Lock()
+	defer limitMu.Unlock()
+
+	lim := limiters[l.namespace]
+	if lim == nil || lim.gen != gen {
+		if lim != nil && lim.timer != nil {
+			// Report what the old rule suppressed rather than losing it
+			lim.timer.Reset(0)
+		}
+		lim = &limiter{gen: gen, limit: resolveLimit(l.namespace)}
+		limiters[l.namespace] = lim
+	}
+
+	if lim.limit == nil || lim.take(limitClock()) {
+		return true
+	}
+
+	lim.suppressed++
+	lim.logger = l
+	if lim.timer == nil {
+		interval := lim.limit.SummaryInterval
+		if interval <= 0 {
+			interval = DefaultSummaryInterval
+		}
+		lim.timer = time.AfterFunc(interval, lim.summarize)
+	}
+	return false
+}
+
+// resolveLimit returns the limit for namespace, or nil if it has none.
+// This must be called with limitMu held.
+func resolveLimit(namespace string) *Limit {
+	for i := len(limitRules) - 1; i >= 0; i-- {
+		if limitRules[i].match.checkEnabled(namespace) {
+			return &limitRules[i].limit
+		}
+	}
+
+	debugMu.RLock()
+	rules := activeSpec.limits
+	debugMu.RUnlock()
+
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].match.checkEnabled(namespace) {
+			return &rules[i].limit
+		}
+	}
+	return nil
+}
+
+// take applies sampling and then the token bucket to one line.
+// This must be called with limitMu held.
+func (lim *limiter) take(now time.Time) bool {
+	l := lim.limit
+
+	lim.count++
+	n := lim.count
+	if l.Sample > 1 && (n-1)%uint64(l.Sample) != 0 {
+		return false
+	}
+	if l.First > 0 || l.Every > 0 {
+		first := uint64(l.First)
+		if n > first && (l.Every <= 0 || (n-first)%uint64(l.Every) != 0) {
+			return false
+		}
+	}
+
+	if l.Rate <= 0 {
+		return true
+	}
+
+	burst := float64(l.Burst)
+	if burst <= 0 {
+		burst = math.Max(1, math.Ceil(l.Rate))
+	}
+	if lim.last.IsZero() {
+		lim.tokens = burst
+	} else {
+		lim.tokens = math.Min(burst, lim.tokens+now.Sub(lim.last).Seconds()*l.Rate)
+	}
+	lim.last = now
+
+	if lim.tokens < 1 {
+		return false
+	}
+	lim.tokens--
+	return true
+}
+
+// summarize writes the "suppressed N messages" line for this namespace.
+func (lim *limiter) summarize() {
+	limitMu.Lock()
+	n, logger := lim.suppressed, lim.logger
+	lim.suppressed = 0
+	lim.timer = nil
+	limitMu.Unlock()
+
+	if n == 0 {
+		return
+	}
+
+	noun := "messages"
+	if n == 1 {
+		noun = "message"
+	}
+	r := logger.record(fmt.Sprintf("suppressed %d %s", n, noun), nil)
+	logger.write(&r)
+}
+
+// parseLimit parses the options after the @ in a DEBUG entry, such as
+// "first=10;every=100" or "rate=50/s;burst=100".
+func parseLimit(options string) (Limit, error) {
+	var l Limit
+	for _, opt := range strings.Split(options, ";") {
+		opt = strings.TrimSpace(opt)
+		if opt == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(opt, "=")
+		if !ok {
+			return Limit{}, fmt.Errorf("expected key=value, got %q", opt)
+		}
+
+		var err error
+		switch key {
+		case "sample":
+			l.Sample, err = strconv.Atoi(value)
+		case "first":
+			l.First, err = strconv.Atoi(value)
+		case "every":
+			l.Every, err = strconv.Atoi(value)
+		case "rate":
+			l.Rate, err = parseRate(value)
+		case "burst":
+			l.Burst, err = strconv.Atoi(value)
+		case "summary":
+			l.SummaryInterval, err = time.ParseDuration(value)
+		default:
+			return Limit{}, fmt.Errorf("unknown option %q", key)
+		}
+		if err != nil {
+			return Limit{}, fmt.Errorf("invalid %s: %v", key, err)
+		}
+	}
+	return l, nil
+}
+
+// parseRate parses a rate such as "50", "50/s", "300/m" or "1000/h" into
+// lines per second.
+func parseRate(s string) (float64, error) {
+	count, unit, _ := strings.Cut(s, "/")
+
+	rate, err := strconv.ParseFloat(count, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	switch unit {
+	case "", "s":
+		return rate, nil
+	case "m":
+		return rate / 60, nil
+	case "h":
+		return rate / 3600, nil
+	default:
+		return 0, fmt.Errorf("unknown unit %q", unit)
+	}
+}
+
+// limitOptionKeys are the option names understood by parseLimit.
+var limitOptionKeys = map[string]bool{
+	"sample": true, "first": true, "every": true, "rate": true, "burst": true, "summary": true,
+}
+
+// parseLimitEntry splits a DEBUG entry such as "app:http@rate=10" into its
+// namespace and limit. The text after the last @ is only taken as options
+// when it is made of key=value pairs with known keys, so namespaces such as
+// "app@v2" keep working. Invalid option values are reported on stderr and
+// ignored.
+func parseLimitEntry(entry string) (string, *Limit) {
+	i := strings.LastIndex(entry, "@")
+	if i < 0 || !isLimitOptions(entry[i+1:]) {
+		return entry, nil
+	}
+	ns, options := entry[:i], entry[i+1:]
+
+	l, err := parseLimit(options)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "debuggo: invalid options for %q in DEBUG: %v\n", ns, err)
+		return ns, nil
+	}
+	if l.IsZero() {
+		return ns, nil
+	}
+	return ns, &l
+}
+
+// isLimitOptions reports whether options looks like limit options: one or
+// more key=value pairs separated by semicolons, all with known keys.
+func isLimitOptions(options string) bool {
+	found := false
+	for _, opt := range strings.Split(options, ";") {
+		opt = strings.TrimSpace(opt)
+		if opt == "" {
+			continue
+		}
+		key, _, ok := strings.Cut(opt, "=")
+		if !ok || !limitOptionKeys[key] {
+			return false
+		}
+		found = true
+	}
+	return found
+}
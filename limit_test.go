@@ -0,0 +1,237 @@
+package debuggo
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// logLines writes n numbered lines to namespace and returns the ones that
+// made it to the output.
+func logLines(t *testing.T, namespace string, n int) []string {
+	t.Helper()
+
+	buf := &syncBuffer{}
+	logger := New(namespace).WithOutput(buf).WithFormatter(messageOnly{})
+	for i := 1; i <= n; i++ {
+		logger.Printf("%d", i)
+	}
+	return strings.Fields(buf.String())
+}
+
+// messageOnly formats just the message, one per line.
+type messageOnly struct{}
+
+func (messageOnly) Format(buf *bytes.Buffer, r *Record) {
+	buf.WriteString(r.Message)
+	buf.WriteByte('\n')
+}
+
+func TestSampling(t *testing.T) {
+	Enable("app:*")
+	defer Disable()
+	defer ClearLimits()
+
+	testCases := []struct {
+		description string
+		limit       Limit
+		expected    string
+	}{
+		{"1 in 3", Limit{Sample: 3}, "1 4 7 10"},
+		{"first 3 then every 4", Limit{First: 3, Every: 4}, "1 2 3 7"},
+		{"first 2 only", Limit{First: 2}, "1 2"},
+		{"zero limit", Limit{}, "1 2 3 4 5 6 7 8 9 10"},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			namespace := fmt.Sprintf("app:sampled%d", i)
+			SetLimit(namespace, tc.limit)
+
+			got := strings.Join(logLines(t, namespace, 10), " ")
+			if got != tc.expected {
+				t.Errorf("Expected lines %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	Enable("app:*")
+	defer Disable()
+	defer ClearLimits()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limitClock = func() time.Time { return now }
+	defer func() { limitClock = time.Now }()
+
+	SetLimit("app:rated", Limit{Rate: 2, Burst: 3})
+
+	if got := len(logLines(t, "app:rated", 10)); got != 3 {
+		t.Errorf("Expected the burst of 3 lines, got %d", got)
+	}
+
+	now = now.Add(time.Second)
+	if got := len(logLines(t, "app:rated", 10)); got != 2 {
+		t.Errorf("Expected 2 lines after refilling for a second, got %d", got)
+	}
+
+	// Each namespace has its own bucket
+	SetLimit("app:*", Limit{Rate: 1})
+	if got := len(logLines(t, "app:other", 10)); got != 1 {
+		t.Errorf("Expected 1 line from a separately limited namespace, got %d", got)
+	}
+}
+
+func TestLimitFromDEBUG(t *testing.T) {
+	Enable("app:*,app:http@sample=2;summary=1h,app:db@first=1,!app:cache@rate=1")
+	defer Disable()
+
+	if !IsEnabled("app:http") || !IsEnabled("app:db") || IsEnabled("app:cache") {
+		t.Error("Options should not change which namespaces are enabled")
+	}
+
+	if got := strings.Join(logLines(t, "app:http", 5), " "); got != "1 3 5" {
+		t.Errorf("Expected sampled lines from app:http, got %q", got)
+	}
+	if got := strings.Join(logLines(t, "app:db", 5), " "); got != "1" {
+		t.Errorf("Expected only the first line from app:db, got %q", got)
+	}
+	if got := len(logLines(t, "app:server", 5)); got != 5 {
+		t.Errorf("Expected unlimited namespaces to log every line, got %d", got)
+	}
+
+	// Replacing the spec drops its limits
+	Enable("app:*")
+	if got := len(logLines(t, "app:db", 5)); got != 5 {
+		t.Errorf("Expected limits to be cleared with the spec, got %d lines", got)
+	}
+}
+
+func TestSuppressedSummary(t *testing.T) {
+	Enable("app:*")
+	defer Disable()
+	defer ClearLimits()
+
+	SetLimit("app:noisy", Limit{First: 2, SummaryInterval: 10 * time.Millisecond})
+
+	buf := &syncBuffer{}
+	logger := New("app:noisy").WithOutput(buf).WithFormatter(messageOnly{})
+	for i := 1; i <= 10; i++ {
+		logger.Printf("line %d", i)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(buf.String(), "suppressed") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	expected := "line 1\nline 2\nsuppressed 8 messages\n"
+	if got := buf.String(); got != expected {
+		t.Errorf("Expected output %q, got %q", expected, got)
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected Limit
+		valid    bool
+	}{
+		{"sample=10", Limit{Sample: 10}, true},
+		{"first=5;every=100", Limit{First: 5, Every: 100}, true},
+		{"rate=50/s;burst=100", Limit{Rate: 50, Burst: 100}, true},
+		{"rate=120/m", Limit{Rate: 2}, true},
+		{"rate=5;summary=30s", Limit{Rate: 5, SummaryInterval: 30 * time.Second}, true},
+		{"rate=5/week", Limit{}, false},
+		{"sample", Limit{}, false},
+		{"speed=fast", Limit{}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := parseLimit(tc.input)
+			if (err == nil) != tc.valid || got != tc.expected {
+				t.Errorf("Expected (%+v, valid=%v), got (%+v, %v)", tc.expected, tc.valid, got, err)
+			}
+		})
+	}
+}
+
+func TestParseLimitEntry(t *testing.T) {
+	testCases := []struct {
+		description string
+		entry       string
+		namespace   string
+		limit       *Limit
+		warning     bool
+	}{
+		{
+			description: "no options",
+			entry:       "app:http",
+			namespace:   "app:http",
+		},
+		{
+			description: "options",
+			entry:       "app:http@first=10;every=100",
+			namespace:   "app:http",
+			limit:       &Limit{First: 10, Every: 100},
+		},
+		{
+			description: "@ in the namespace",
+			entry:       "app@v2",
+			namespace:   "app@v2",
+		},
+		{
+			description: "@ in the namespace with options",
+			entry:       "app@v2@sample=10",
+			namespace:   "app@v2",
+			limit:       &Limit{Sample: 10},
+		},
+		{
+			description: "unknown key is part of the namespace",
+			entry:       "app@speed=fast",
+			namespace:   "app@speed=fast",
+		},
+		{
+			description: "invalid value is reported",
+			entry:       "app:http@rate=5/week",
+			namespace:   "app:http",
+			warning:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			var ns string
+			var limit *Limit
+			stderr := captureStderr(t, func() {
+				ns, limit = parseLimitEntry(tc.entry)
+			})
+
+			if ns != tc.namespace {
+				t.Errorf("Expected namespace %q, got %q", tc.namespace, ns)
+			}
+			if (limit == nil) != (tc.limit == nil) || (limit != nil && *limit != *tc.limit) {
+				t.Errorf("Expected limit %+v, got %+v", tc.limit, limit)
+			}
+			if (stderr != "") != tc.warning {
+				t.Errorf("Expected warning=%v, got %q", tc.warning, stderr)
+			}
+		})
+	}
+}
+
+func TestNamespaceWithAt(t *testing.T) {
+	Enable("app@v2")
+	defer Disable()
+
+	if !IsEnabled("app@v2") {
+		t.Error("Expected app@v2 to be enabled")
+	}
+	if IsEnabled("app") {
+		t.Error("Expected app not to be enabled by app@v2")
+	}
+}
@@ -0,0 +1,376 @@
+package debuggo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultDelimiter separates namespace segments when Extend is called
+// without an explicit delimiter.
+const DefaultDelimiter = ":"
+
+var (
+	defaultOutput    io.Writer
+	defaultFormatter Formatter = TextFormatter{}
+	settingsMu       sync.RWMutex
+)
+
+// SetOutput changes where debug lines are written for every logger that has
+// not been given its own output with Logger.WithOutput, including the
+// functions returned by Debug. Passing nil restores the default, os.Stderr.
+//
+// Each debug line is written with a single Write call, and writes are
+// serialized, so w does not need to be safe for concurrent use. w must not
+// log through debuggo itself.
+//
+// SetOutput returns the previous output (nil for the default) so that it can
+// be restored later.
+//
+// Example:
+//
+//	f, _ := os.Create("debug.log")
+//	debuggo.SetOutput(f)
+func SetOutput(w io.Writer) (previous io.Writer) {
+	noteHelper(w)
+
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	previous = defaultOutput
+	defaultOutput = w
+	return previous
+}
+
+// SetFormatter changes how debug lines are rendered for every logger that has
+// not been given its own formatter with Logger.WithFormatter. Passing nil
+// restores the default TextFormatter. It returns the previous formatter so
+// that it can be restored later.
+func SetFormatter(f Formatter) (previous Formatter) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	if f == nil {
+		f = TextFormatter{}
+	}
+	previous = defaultFormatter
+	defaultFormatter = f
+	return previous
+}
+
+// Logger is a debug logger bound to a namespace. Unlike the bare function
+// returned by Debug, a Logger can be extended into child namespaces and
+// carries its own output and formatter settings.
+//
+// Loggers are immutable: the With* methods and Extend return new loggers,
+// so a Logger can be shared freely between goroutines.
+//
+// Example:
+//
+//	var server = debuggo.New("app:server")
+//	var httpDebug = server.Extend("http") // app:server:http
+//
+//	httpDebug.Printf("listening on %s", addr)
+type Logger struct {
+	namespace string
+	output    io.Writer
+	formatter Formatter
+}
+
+// New returns a Logger for the given namespace. The logger writes to the
+// package output (see SetOutput) using the package formatter (see
+// SetFormatter) until configured otherwise.
+func New(namespace string) *Logger {
+	return &Logger{namespace: namespace}
+}
+
+// Extend returns a child logger whose namespace is the parent's namespace
+// followed by the delimiter and name, mirroring extend() in the Node.js debug
+// package. The delimiter defaults to DefaultDelimiter. The child inherits the
+// parent's output and formatter.
+//
+// Example:
+//
+//	db := debuggo.New("app:db")
+//	db.Extend("query")      // app:db:query
+//	db.Extend("pool", "/")  // app:db/pool
+func (l *Logger) Extend(name string, delimiter ...string) *Logger {
+	delim := DefaultDelimiter
+	if len(delimiter) > 0 {
+		delim = delimiter[0]
+	}
+
+	child := *l
+	child.namespace = l.namespace + delim + name
+	return &child
+}
+
+// WithOutput returns a copy of the logger that writes to w instead of the
+// package output. Passing nil makes the copy follow the package output again.
+func (l *Logger) WithOutput(w io.Writer) *Logger {
+	noteHelper(w)
+
+	c := *l
+	c.output = w
+	return &c
+}
+
+// WithFormatter returns a copy of the logger that renders lines with f instead
+// of the package formatter. Passing nil makes the copy follow the package
+// formatter again.
+func (l *Logger) WithFormatter(f Formatter) *Logger {
+	c := *l
+	c.formatter = f
+	return &c
+}
+
+// Namespace returns the namespace the logger writes under.
+func (l *Logger) Namespace() string {
+	return l.namespace
+}
+
+// Enabled reports whether the logger's namespace is enabled under the current
+// DEBUG settings. It is the Logger equivalent of IsEnabled and saves
+// repeating the namespace string when guarding expensive debug code:
+//
+//	if metrics.Enabled() {
+//	    metrics.Printf("System metrics: %+v", calculateDetailedMetrics())
+//	}
+func (l *Logger) Enabled() bool {
+	return IsEnabled(l.namespace)
+}
+
+// Print logs its operands, formatted as by fmt.Sprint, if the logger's
+// namespace is enabled.
+func (l *Logger) Print(args ...interface{}) {
+	if h := l.helper(); h != nil {
+		h.Helper()
+	}
+	l.log(nil, message{args: args, mode: modePrint})
+}
+
+// Println logs its operands, formatted as by fmt.Sprintln, if the logger's
+// namespace is enabled. Operands are always separated by spaces; the
+// trailing newline is supplied by the formatter.
+func (l *Logger) Println(args ...interface{}) {
+	if h := l.helper(); h != nil {
+		h.Helper()
+	}
+	l.log(nil, message{args: args, mode: modePrintln})
+}
+
+// Write implements the io.Writer interface so a Logger can be handed to code
+// that expects a writer. Each call is logged as one message with a single
+// trailing newline removed. Write always reports len(p) bytes written, even
+// when the namespace is disabled and nothing is output.
+func (l *Logger) Write(p []byte) (n int, err error) {
+	// Check first to avoid copying p when nothing will use it
+	if !IsEnabled(l.namespace) && activeRecorder() == nil {
+		return len(p), nil
+	}
+	if h := l.helper(); h != nil {
+		h.Helper()
+	}
+	l.log(nil, message{format: strings.TrimSuffix(string(p), "\n"), mode: modeText})
+	return len(p), nil
+}
+
+// Printf logs a formatted message if the logger's namespace is enabled.
+// Its signature matches the function returned by Debug, so a method value
+// can be used anywhere such a function is expected:
+//
+//	var debug = debuggo.New("app").Extend("cache").Printf
+func (l *Logger) Printf(format string, args ...interface{}) {
+	if h := l.helper(); h != nil {
+		h.Helper()
+	}
+	l.log(nil, message{format: format, args: args})
+}
+
+// log is the common path for every Logger method. If the namespace is
+// enabled, the message is rendered, passed through any context hooks and
+// written to the output. Lines are also kept by the flight recorder when it
+// is on, in which case disabled lines are stored unrendered.
+func (l *Logger) log(ctx context.Context, m message) {
+	if h := l.helper(); h != nil {
+		h.Helper()
+	}
+	l.emit(ctx, l.allowed(), m)
+}
+
+// allowed reports whether the next line is written: the namespace must be
+// enabled and the line must not be held back by a Limit.
+func (l *Logger) allowed() bool {
+	return IsEnabled(l.namespace) && l.allow()
+}
+
+// emit is log with allowed already checked, so that callers writing several
+// rows of one value, such as Dump, can check it once for all of them.
+func (l *Logger) emit(ctx context.Context, enabled bool, m message) {
+	recorder := activeRecorder()
+	// Lines held back by a Limit are treated like lines from a disabled
+	// namespace: not written, but still kept by the flight recorder
+	if !enabled && recorder == nil {
+		return
+	}
+
+	var fields []Field
+	if ctx != nil {
+		// Clip the shared slice so hooks appending fields never write into it
+		fields = FromContext(ctx)
+		fields = fields[:len(fields):len(fields)]
+	}
+	if goroutineFields.Load() {
+		fields = appendGoroutineFields(ctx, fields)
+	}
+
+	if !enabled {
+		recorder.addLazy(time.Now(), l.namespace, m, fields)
+		return
+	}
+	if h := l.helper(); h != nil {
+		h.Helper()
+	}
+
+	r := l.record(m.String(), fields)
+	if ctx != nil {
+		runContextHooks(ctx, &r)
+	}
+	if recorder != nil {
+		recorder.add(r)
+	}
+	if l.dedup(&r) {
+		return
+	}
+	l.write(&r)
+}
+
+// messageMode selects how a message's text is rendered.
+type messageMode int
+
+const (
+	modePrintf messageMode = iota
+	modePrint
+	modePrintln
+	// modeText means format already holds the final text
+	modeText
+	// modeRedacted means format holds the final text with redactions
+	// already applied
+	modeRedacted
+)
+
+// message is the text of a debug line, kept as its format and arguments so
+// that rendering can be skipped or deferred until the text is needed.
+type message struct {
+	format string
+	args   []interface{}
+	mode   messageMode
+}
+
+// String renders the message text, with redactions applied.
+func (m message) String() string {
+	if m.mode == modeRedacted {
+		return m.format
+	}
+	return redactText(m.render())
+}
+
+// render formats the message, masking struct fields tagged for redaction.
+func (m message) render() string {
+	switch m.mode {
+	case modePrint:
+		return fmt.Sprint(redactArgs(m.args)...)
+	case modePrintln:
+		msg := fmt.Sprintln(redactArgs(m.args)...)
+		return msg[:len(msg)-1]
+	case modeText, modeRedacted:
+		return m.format
+	default:
+		return fmt.Sprintf(m.format, redactPrintfArgs(m.format, m.args)...)
+	}
+}
+
+// record builds a Record for msg under this logger's namespace, with
+// redactions applied to the field values.
+func (l *Logger) record(msg string, fields []Field) Record {
+	return Record{
+		Time:      time.Now(),
+		Namespace: l.namespace,
+		Message:   msg,
+		Fields:    redactFields(fields),
+	}
+}
+
+// write formats r into a pooled buffer and writes it to the logger's output
+// as a single record.
+func (l *Logger) write(r *Record) {
+	out, f := l.settings()
+	if h, ok := out.(helper); ok {
+		h.Helper()
+	}
+
+	buf := getBuffer()
+	f.Format(buf, r)
+	writeOutput(out, buf.Bytes())
+	putBuffer(buf)
+}
+
+// settings resolves the output and formatter for this logger, falling back
+// to the package defaults. os.Stderr is looked up at call time so that
+// redirecting it (as tests do) keeps working.
+func (l *Logger) settings() (io.Writer, Formatter) {
+	out, f := l.output, l.formatter
+	if out == nil || f == nil {
+		settingsMu.RLock()
+		if out == nil {
+			out = defaultOutput
+		}
+		if f == nil {
+			f = defaultFormatter
+		}
+		settingsMu.RUnlock()
+	}
+	if out == nil {
+		out = os.Stderr
+	}
+	return out, f
+}
+
+// packageSettings resolves the package output and formatter, as used by
+// loggers without settings of their own.
+func packageSettings() (io.Writer, Formatter) {
+	return (&Logger{}).settings()
+}
+
+// helper is implemented by outputs that attribute each line to the code that
+// logged it, such as the testing.TB adapter in the debuggotest package. Every
+// debuggo function on the logging path calls Helper on such an output, so the
+// reported location skips debuggo's own frames.
+type helper interface {
+	Helper()
+}
+
+// helperSeen is set once any helper output has been configured, so that
+// loggers only look up their output to mark frames when it can matter.
+var helperSeen atomic.Bool
+
+// noteHelper records that w is a helper output.
+func noteHelper(w io.Writer) {
+	if _, ok := w.(helper); ok {
+		helperSeen.Store(true)
+	}
+}
+
+// helper returns the logger's output if it is a helper output, or nil. The
+// caller must invoke Helper itself, since that marks the calling function.
+func (l *Logger) helper() helper {
+	if !helperSeen.Load() {
+		return nil
+	}
+	out, _ := l.settings()
+	h, _ := out.(helper)
+	return h
+}
@@ -0,0 +1,167 @@
+package debuggo
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLoggerExtend(t *testing.T) {
+	testCases := []struct {
+		parent      string
+		name        string
+		delimiter   []string
+		expected    string
+		description string
+	}{
+		{"app:server", "http", nil, "app:server:http", "Default delimiter is a colon"},
+		{"app", "db", []string{"/"}, "app/db", "Custom delimiter is used"},
+		{"app", "db", []string{""}, "appdb", "Empty delimiter concatenates"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			child := New(tc.parent).Extend(tc.name, tc.delimiter...)
+			if child.namespace != tc.expected {
+				t.Errorf("Expected namespace %s, got %s", tc.expected, child.namespace)
+			}
+		})
+	}
+}
+
+func TestLoggerExtendInheritsSettings(t *testing.T) {
+	os.Setenv("DEBUG", "app:*")
+	ReloadDebugSettings()
+
+	buf := &bytes.Buffer{}
+	parent := New("app").WithOutput(buf).WithFormatter(JSONFormatter{})
+	child := parent.Extend("server").Extend("http")
+
+	child.Printf("Listening on %d", 8080)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected JSON output from inherited formatter, got %q: %v", buf.String(), err)
+	}
+	if entry["namespace"] != "app:server:http" {
+		t.Errorf("Expected namespace app:server:http, got %v", entry["namespace"])
+	}
+	if entry["message"] != "Listening on 8080" {
+		t.Errorf("Expected formatted message, got %v", entry["message"])
+	}
+
+	// Extending must not change the parent
+	if parent.namespace != "app" {
+		t.Errorf("Parent namespace changed to %s", parent.namespace)
+	}
+}
+
+func TestLoggerPrintfGating(t *testing.T) {
+	os.Setenv("DEBUG", "app:*,!app:db")
+	ReloadDebugSettings()
+
+	buf := &bytes.Buffer{}
+	app := New("app").WithOutput(buf)
+
+	app.Extend("db").Printf("hidden")
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output for negated namespace, got %q", buf.String())
+	}
+
+	app.Extend("api").Printf("shown %s", "here")
+	if !strings.HasSuffix(buf.String(), " app:api shown here\n") {
+		t.Errorf("Unexpected output %q", buf.String())
+	}
+}
+
+func TestSetOutput(t *testing.T) {
+	os.Setenv("DEBUG", "*")
+	ReloadDebugSettings()
+
+	buf := &bytes.Buffer{}
+	SetOutput(buf)
+	defer SetOutput(nil)
+
+	Debug("module")("Hello %s", "world")
+
+	if !strings.HasSuffix(buf.String(), " module Hello world\n") {
+		t.Errorf("Expected Debug to write to the package output, got %q", buf.String())
+	}
+}
+
+func TestSetFormatter(t *testing.T) {
+	os.Setenv("DEBUG", "*")
+	ReloadDebugSettings()
+
+	buf := &bytes.Buffer{}
+	SetFormatter(TextFormatter{TimeFormat: "[15]"})
+	defer SetFormatter(nil)
+
+	New("module").WithOutput(buf).Printf("formatted")
+
+	if !strings.HasPrefix(buf.String(), "[") || !strings.HasSuffix(buf.String(), "] module formatted\n") {
+		t.Errorf("Expected custom time format, got %q", buf.String())
+	}
+}
+
+func TestLoggerIntrospection(t *testing.T) {
+	os.Setenv("DEBUG", "app:metrics")
+	ReloadDebugSettings()
+
+	metrics := New("app").Extend("metrics")
+	if metrics.Namespace() != "app:metrics" {
+		t.Errorf("Expected namespace app:metrics, got %s", metrics.Namespace())
+	}
+	if !metrics.Enabled() {
+		t.Error("app:metrics should be enabled")
+	}
+	if New("app:other").Enabled() {
+		t.Error("app:other should be disabled")
+	}
+}
+
+func TestLoggerPrintMethods(t *testing.T) {
+	os.Setenv("DEBUG", "app")
+	ReloadDebugSettings()
+
+	testCases := []struct {
+		log         func(l *Logger)
+		expected    string
+		description string
+	}{
+		{func(l *Logger) { l.Printf("%d items", 3) }, "3 items", "Printf formats like fmt.Printf"},
+		{func(l *Logger) { l.Print("a", 1, 2, "b") }, "a1 2b", "Print formats like fmt.Print"},
+		{func(l *Logger) { l.Println("a", 1, 2, "b") }, "a 1 2 b", "Println formats like fmt.Println"},
+		{func(l *Logger) { l.Write([]byte("raw line\n")) }, "raw line", "Write trims one trailing newline"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			tc.log(New("app").WithOutput(buf))
+
+			if !strings.HasSuffix(buf.String(), " app "+tc.expected+"\n") {
+				t.Errorf("Expected message %q, got %q", tc.expected, buf.String())
+			}
+
+			buf.Reset()
+			tc.log(New("disabled").WithOutput(buf))
+			if buf.Len() != 0 {
+				t.Errorf("Expected no output for disabled namespace, got %q", buf.String())
+			}
+		})
+	}
+}
+
+func TestLoggerWriteReportsLength(t *testing.T) {
+	os.Setenv("DEBUG", "")
+	ReloadDebugSettings()
+
+	msg := []byte("dropped\n")
+	n, err := New("app").WithOutput(&bytes.Buffer{}).Write(msg)
+	if err != nil || n != len(msg) {
+		t.Errorf("Expected (%d, nil), got (%d, %v)", len(msg), n, err)
+	}
+}
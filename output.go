@@ -0,0 +1,65 @@
+package debuggo
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// maxPooledBuffer is the largest buffer returned to the pool; occasional huge
+// debug lines should not pin their memory for the life of the process.
+const maxPooledBuffer = 64 << 10
+
+var (
+	bufferPool = sync.Pool{
+		New: func() interface{} { return new(bytes.Buffer) },
+	}
+	// outputLocks holds a *sync.Mutex per output writer
+	outputLocks sync.Map
+	// sharedOutputMu serializes writers that cannot be used as map keys
+	sharedOutputMu sync.Mutex
+)
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer resets buf and returns it to the pool.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// writeOutput writes one complete record to w with a single Write call.
+// Writes to the same writer from every logger and PrefixWriter are
+// serialized, so records are never interleaved even when w itself is not
+// safe for concurrent use, while a slow writer does not hold up loggers
+// writing elsewhere. Writers must not retain p, as required by the io.Writer
+// contract; the buffer is reused for later records.
+func writeOutput(w io.Writer, p []byte) {
+	if h, ok := w.(helper); ok {
+		h.Helper()
+	}
+
+	mu := outputLock(w)
+	mu.Lock()
+	defer mu.Unlock()
+	w.Write(p)
+}
+
+// outputLock returns the mutex serializing writes to w.
+func outputLock(w io.Writer) *sync.Mutex {
+	if !reflect.TypeOf(w).Comparable() {
+		return &sharedOutputMu
+	}
+	if mu, ok := outputLocks.Load(w); ok {
+		return mu.(*sync.Mutex)
+	}
+	mu, _ := outputLocks.LoadOrStore(w, &sync.Mutex{})
+	return mu.(*sync.Mutex)
+}
@@ -0,0 +1,177 @@
+package debuggo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// trickleWriter simulates a non-atomic sink: it copies each write into the
+// shared buffer one byte at a time, yielding in between, so concurrent
+// unserialized writes would interleave. It is deliberately not locked.
+type trickleWriter struct {
+	buf []byte
+}
+
+func (tw *trickleWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		tw.buf = append(tw.buf, b)
+		runtime.Gosched()
+	}
+	return len(p), nil
+}
+
+func TestConcurrentWritesDoNotInterleave(t *testing.T) {
+	os.Setenv("DEBUG", "*")
+	ReloadDebugSettings()
+
+	sink := &trickleWriter{}
+	SetOutput(sink)
+	defer SetOutput(nil)
+
+	const goroutines = 8
+	const messages = 20
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+
+			// Each goroutine logs long, multi-line messages made of one letter
+			letter := string(rune('a' + g))
+			chunk := strings.Repeat(letter, 64)
+			debug := Debug("ns" + letter)
+			logger := New("ns" + letter)
+
+			for i := 0; i < messages; i++ {
+				if i%2 == 0 {
+					debug("%s\n%s", chunk, chunk)
+				} else {
+					logger.Println(chunk)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(string(sink.buf), "\n"), "\n")
+	expectedLines := goroutines * messages * 3 / 2
+	if len(lines) != expectedLines {
+		t.Fatalf("Expected %d lines, got %d", expectedLines, len(lines))
+	}
+
+	for _, line := range lines {
+		// Strip the "time namespace " prefix from the first line of a record
+		fields := strings.Fields(line)
+		body := fields[len(fields)-1]
+		if len(fields) == 3 {
+			if fields[1] != "ns"+body[:1] {
+				t.Fatalf("Line has mismatched namespace: %q", line)
+			}
+		} else if len(fields) != 1 {
+			t.Fatalf("Line was interleaved: %q", line)
+		}
+		if body != strings.Repeat(body[:1], 64) {
+			t.Fatalf("Line was interleaved: %q", line)
+		}
+	}
+}
+
+// blockingWriter blocks every Write until release is closed.
+type blockingWriter struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	w.entered <- struct{}{}
+	<-w.release
+	return len(p), nil
+}
+
+func TestSlowOutputDoesNotBlockOtherOutputs(t *testing.T) {
+	Enable("app:*")
+	defer Disable()
+
+	slow := &blockingWriter{entered: make(chan struct{}, 1), release: make(chan struct{})}
+	defer close(slow.release)
+	go New("app:slow").WithOutput(slow).Print("stuck")
+	<-slow.entered
+
+	done := make(chan struct{})
+	buf := &syncBuffer{}
+	go func() {
+		defer close(done)
+		New("app:fast").WithOutput(buf).Print("written")
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a blocked output not to hold up a logger writing elsewhere")
+	}
+	if !strings.Contains(buf.String(), "written") {
+		t.Errorf("Expected the fast output to be written, got %q", buf.String())
+	}
+}
+
+func TestConcurrentPrefixWriterWrites(t *testing.T) {
+	origStderr := os.Stderr
+	defer func() { os.Stderr = origStderr }()
+
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+
+	out := make(chan []byte)
+	go func() {
+		buf := &bytes.Buffer{}
+		buf.ReadFrom(r)
+		out <- buf.Bytes()
+	}()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			pw := &PrefixWriter{Prefix: fmt.Sprintf("p%d", g)}
+			for i := 0; i < 50; i++ {
+				pw.Write([]byte(strings.Repeat(fmt.Sprint(g), 100) + "\n"))
+			}
+		}(g)
+	}
+	wg.Wait()
+	w.Close()
+
+	for _, line := range strings.Split(strings.TrimSuffix(string(<-out), "\n"), "\n") {
+		var g int
+		var body string
+		if _, err := fmt.Sscanf(line, "p%d %s", &g, &body); err != nil {
+			t.Fatalf("Malformed line %q: %v", line, err)
+		}
+		if body != strings.Repeat(fmt.Sprint(g), 100) {
+			t.Fatalf("Line was interleaved: %q", line)
+		}
+	}
+}
+
+func BenchmarkDebugEnabled(b *testing.B) {
+	os.Setenv("DEBUG", "*")
+	ReloadDebugSettings()
+
+	SetOutput(io.Discard)
+	defer SetOutput(nil)
+
+	debug := Debug("bench")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		debug("message %d", i)
+	}
+}
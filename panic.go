@@ -0,0 +1,89 @@
+package debuggo
+
+import (
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// PanicNamespace is the namespace of the line RecoverAndDump writes for the
+// panic value itself.
+const PanicNamespace = "debuggo:panic"
+
+// RecoverAndDump writes post-mortem context to the package output when the
+// surrounding function panics, then re-panics with the original value. It
+// must be deferred directly:
+//
+//	func main() {
+//	    debuggo.EnableFlightRecorder(1000)
+//	    defer debuggo.RecoverAndDump()
+//	    ...
+//	}
+//
+// The dump contains the lines held by the flight recorder (see
+// EnableFlightRecorder), a line with the panic value, and the stack of the
+// panicking goroutine. Pass DEBUG-style patterns to only include matching
+// namespaces, e.g. RecoverAndDump("app:db:*", "!app:db:pool"). When the
+// surrounding function does not panic, RecoverAndDump does nothing.
+func RecoverAndDump(namespaces ...string) {
+	// recover only works when called directly by the deferred function, so
+	// this cannot delegate to RecoverAndDumpN
+	if v := recover(); v != nil {
+		dumpPanic(v, 0, namespaces)
+		panic(v)
+	}
+}
+
+// RecoverAndDumpN is like RecoverAndDump, but only includes the last n
+// matching lines from the flight recorder. It must also be deferred directly:
+//
+//	defer debuggo.RecoverAndDumpN(50, "app:*")
+func RecoverAndDumpN(n int, namespaces ...string) {
+	if v := recover(); v != nil {
+		dumpPanic(v, n, namespaces)
+		panic(v)
+	}
+}
+
+// dumpPanic writes the recent history, then the panic value and the stack as
+// one redacted record, to the package output as one write. A limit of 0 includes all recorded lines.
+func dumpPanic(v interface{}, limit int, namespaces []string) {
+	out, f := packageSettings()
+
+	var records []Record
+	if fr := activeRecorder(); fr != nil {
+		records = fr.snapshot()
+	}
+
+	if len(namespaces) > 0 {
+		spec := parseSpec(strings.Join(namespaces, ","))
+		matched := records[:0]
+		for _, r := range records {
+			if spec.checkEnabled(r.Namespace) {
+				matched = append(matched, r)
+			}
+		}
+		records = matched
+	}
+
+	if limit > 0 && len(records) > limit {
+		records = records[len(records)-limit:]
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	for i := range records {
+		f.Format(buf, &records[i])
+	}
+	// The stack goes in the message so that formatters such as JSONFormatter
+	// still write one record per line
+	panicMsg := message{format: "panic: %v", args: []interface{}{v}}.String()
+	f.Format(buf, &Record{
+		Time:      time.Now(),
+		Namespace: PanicNamespace,
+		Message:   panicMsg + "\n" + strings.TrimSuffix(string(debug.Stack()), "\n"),
+	})
+
+	writeOutput(out, buf.Bytes())
+}
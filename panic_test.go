@@ -0,0 +1,180 @@
+package debuggo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// recoverFrom calls fn, which is expected to panic, and returns the value
+// that escaped it.
+func recoverFrom(fn func()) (recovered interface{}) {
+	defer func() { recovered = recover() }()
+	fn()
+	return nil
+}
+
+func TestRecoverAndDump(t *testing.T) {
+	os.Setenv("DEBUG", "app:api")
+	ReloadDebugSettings()
+
+	EnableFlightRecorder(100)
+	defer EnableFlightRecorder(0)
+
+	out := &bytes.Buffer{}
+	SetOutput(out)
+	defer SetOutput(nil)
+
+	Debug("app:api")("serving request")
+	Debug("app:db")("query failed")
+	out.Reset()
+
+	recovered := recoverFrom(func() {
+		defer RecoverAndDump()
+		panic("boom")
+	})
+	if recovered != "boom" {
+		t.Errorf("Expected the panic to be re-raised with the original value, got %v", recovered)
+	}
+
+	dump := out.String()
+	for _, want := range []string{
+		" app:api serving request\n",
+		" app:db query failed\n",
+		" " + PanicNamespace + " panic: boom\n",
+		"goroutine ",
+		"TestRecoverAndDump",
+	} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("Expected dump to contain %q, got %q", want, dump)
+		}
+	}
+}
+
+func TestRecoverAndDumpSelection(t *testing.T) {
+	os.Setenv("DEBUG", "")
+	ReloadDebugSettings()
+
+	EnableFlightRecorder(100)
+	defer EnableFlightRecorder(0)
+
+	out := &bytes.Buffer{}
+	SetOutput(out)
+	defer SetOutput(nil)
+
+	for i := 1; i <= 3; i++ {
+		Debug("app:db")("db %d", i)
+		Debug("app:db:pool")("pool %d", i)
+		Debug("app:api")("api %d", i)
+	}
+
+	testCases := []struct {
+		fn          func()
+		included    []string
+		excluded    []string
+		description string
+	}{
+		{
+			func() {
+				defer RecoverAndDump("app:*", "!app:db:pool")
+				panic(fmt.Errorf("failed"))
+			},
+			[]string{"app:db db 1", "app:api api 3"},
+			[]string{"pool"},
+			"Namespaces are selected with DEBUG syntax",
+		},
+		{
+			func() {
+				defer RecoverAndDumpN(2)
+				panic(fmt.Errorf("failed"))
+			},
+			[]string{"app:db:pool pool 3", "app:api api 3"},
+			[]string{"db 3", "api 2"},
+			"Only the last N lines are included",
+		},
+		{
+			func() {
+				defer RecoverAndDumpN(1, "app:db")
+				panic(fmt.Errorf("failed"))
+			},
+			[]string{"app:db db 3"},
+			[]string{"db 2", "pool", "api"},
+			"Limit applies after selection",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			out.Reset()
+			if recoverFrom(tc.fn) == nil {
+				t.Fatal("Expected the panic to be re-raised")
+			}
+
+			dump := out.String()
+			for _, want := range tc.included {
+				if !strings.Contains(dump, want) {
+					t.Errorf("Expected dump to contain %q, got %q", want, dump)
+				}
+			}
+			for _, unwanted := range tc.excluded {
+				if strings.Contains(dump, unwanted) {
+					t.Errorf("Expected dump not to contain %q, got %q", unwanted, dump)
+				}
+			}
+		})
+	}
+}
+
+func TestRecoverAndDumpWithoutPanic(t *testing.T) {
+	out := &bytes.Buffer{}
+	SetOutput(out)
+	defer SetOutput(nil)
+
+	func() {
+		defer RecoverAndDump()
+	}()
+
+	if out.Len() != 0 {
+		t.Errorf("Expected no output without a panic, got %q", out.String())
+	}
+}
+
+func TestRecoverAndDumpPanicRecord(t *testing.T) {
+	prev := SetRedactions(RedactEmails)
+	defer SetRedactions(prev...)
+
+	out := &bytes.Buffer{}
+	SetOutput(out)
+	defer SetOutput(nil)
+	SetFormatter(JSONFormatter{})
+	defer SetFormatter(nil)
+
+	recoverFrom(func() {
+		defer RecoverAndDump()
+		panic(credentials{User: "jane@example.com", Password: "hunter2"})
+	})
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected the panic to be one JSON line, got %q", out.String())
+	}
+	var r struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &r); err != nil {
+		t.Fatalf("Expected valid JSON, got %q: %v", lines[0], err)
+	}
+	for _, want := range []string{"panic: {", "goroutine ", "TestRecoverAndDumpPanicRecord"} {
+		if !strings.Contains(r.Message, want) {
+			t.Errorf("Expected panic message to contain %q, got %q", want, r.Message)
+		}
+	}
+	for _, secret := range []string{"hunter2", "jane@example.com"} {
+		if strings.Contains(r.Message, secret) {
+			t.Errorf("Expected %q to be redacted, got %q", secret, r.Message)
+		}
+	}
+}
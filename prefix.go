@@ -0,0 +1,214 @@
+package debuggo
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// maxPartialLine is the longest line a PrefixWriter buffers while waiting for
+// a newline; longer lines are written in pieces.
+const maxPartialLine = 64 << 10
+
+// PrefixWriter is a writer that adds a prefix to each line written.
+// It can also be configured to ignore certain phrases.
+// Implements io.Writer interface for integration with standard libraries.
+//
+// This can be useful for:
+//   - Prefixing output from subprocesses or other libraries
+//   - Filtering out unwanted messages
+//   - Integrating with libraries that expect an io.Writer
+//
+// A PrefixWriter created with NewPrefixWriter treats Prefix as a namespace
+// and behaves like a Debug logger instead: lines are only written when the
+// namespace is enabled, and they get the usual timestamp and formatting.
+//
+// To route the standard library log package through debuggo, use
+// RedirectStdLog or NewStdLogger instead.
+//
+// Writes are split into lines and each line is prefixed separately. A line
+// without its newline yet is buffered until the rest arrives, so call Flush
+// or Close to write out any trailing partial line. The zero value is ready to
+// use; a PrefixWriter must not be copied after first use.
+//
+// Example:
+//
+//	// Prefix a subprocess's output
+//	cmd.Stderr = &debuggo.PrefixWriter{Prefix: "app:worker"}
+//
+//	// Filter out health check logs
+//	logger := &debuggo.PrefixWriter{
+//	    Prefix: "app:api",
+//	    Ignores: []string{"/health", "/ping"},
+//	}
+//
+//	// Ignore successful health checks but keep failures
+//	logger := &debuggo.PrefixWriter{
+//	    Prefix:         "app:api",
+//	    IgnorePatterns: []*regexp.Regexp{regexp.MustCompile(`GET /health .* 200`)},
+//	}
+type PrefixWriter struct {
+	// Prefix is added to the beginning of each line
+	Prefix string
+	// Ignores is a list of phrases that will cause the line to be skipped if found
+	Ignores []string
+	// IgnorePatterns skips lines matching any of these regular expressions
+	IgnorePatterns []*regexp.Regexp
+	// Includes, when not empty, skips every line that does not match at least
+	// one of these regular expressions
+	Includes []*regexp.Regexp
+	// Filter, when set, is called with each line that passed the rules above;
+	// the line is skipped if it returns false
+	Filter func(line string) bool
+
+	// logger is set by NewPrefixWriter to gate and format lines
+	logger *Logger
+
+	mu      sync.Mutex
+	partial []byte
+}
+
+// NewPrefixWriter returns a PrefixWriter that writes each line as a debug
+// line of namespace, so nothing is written unless namespace is enabled under
+// DEBUG. Lines go to the package output with the package formatter, exactly
+// like lines from Debug(namespace).
+//
+// Example:
+//
+//	cmd := exec.Command("make")
+//	cmd.Stdout = debuggo.NewPrefixWriter("app:build")
+func NewPrefixWriter(namespace string) *PrefixWriter {
+	return &PrefixWriter{Prefix: namespace, logger: New(namespace)}
+}
+
+// Write implements the io.Writer interface.
+// It adds a prefix to each line and filters out lines containing ignored phrases.
+//
+// The method:
+//   - Splits the text into lines, buffering a trailing line with no newline
+//   - Skips lines rejected by Ignores, IgnorePatterns, Includes or Filter
+//   - Prepends the Prefix to each remaining line and writes them to os.Stderr
+//     in a single write that never interleaves with other debug output, or
+//     logs each line under the namespace if created with NewPrefixWriter
+//   - Always returns the original input length to satisfy the io.Writer contract
+func (pw *PrefixWriter) Write(p []byte) (n int, err error) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	if pw.logger != nil && !pw.logger.Enabled() && activeRecorder() == nil {
+		// Nothing would be written, so skip splitting; a partial line
+		// pending from before the namespace was disabled is dropped
+		pw.partial = pw.partial[:0]
+		return len(p), nil
+	}
+
+	pw.partial = append(pw.partial, p...)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	rest := pw.partial
+	for {
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			break
+		}
+		pw.appendLine(buf, rest[:i])
+		rest = rest[i+1:]
+	}
+	for len(rest) > maxPartialLine {
+		pw.appendLine(buf, rest[:maxPartialLine])
+		rest = rest[maxPartialLine:]
+	}
+	pw.partial = append(pw.partial[:0], rest...)
+
+	pw.output(buf)
+	return len(p), nil
+}
+
+// Flush writes any buffered partial line as a complete line.
+func (pw *PrefixWriter) Flush() error {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	if len(pw.partial) == 0 {
+		return nil
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	pw.appendLine(buf, pw.partial)
+	pw.partial = pw.partial[:0]
+	pw.output(buf)
+	return nil
+}
+
+// Close flushes any buffered partial line. The writer can still be used
+// afterwards.
+func (pw *PrefixWriter) Close() error {
+	return pw.Flush()
+}
+
+// appendLine adds one prefixed line to buf unless it is ignored. Lines of a
+// namespace-aware writer are logged directly instead.
+// This must be called with the lock held.
+func (pw *PrefixWriter) appendLine(buf *bytes.Buffer, line []byte) {
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	text := string(line)
+
+	if !pw.keep(text) {
+		return
+	}
+
+	if pw.logger != nil {
+		pw.logger.log(nil, message{format: text, mode: modeText})
+		return
+	}
+
+	buf.WriteString(pw.Prefix)
+	buf.WriteByte(' ')
+	buf.WriteString(redactText(text))
+	buf.WriteByte('\n')
+}
+
+// keep reports whether a line passes the writer's filters. They are applied
+// in order: Ignores, IgnorePatterns, Includes and finally Filter.
+func (pw *PrefixWriter) keep(line string) bool {
+	// Check if the line contains any ignored phrases
+	for _, ignore := range pw.Ignores {
+		if strings.Contains(line, ignore) {
+			return false
+		}
+	}
+
+	for _, re := range pw.IgnorePatterns {
+		if re.MatchString(line) {
+			return false
+		}
+	}
+
+	if len(pw.Includes) > 0 {
+		included := false
+		for _, re := range pw.Includes {
+			if re.MatchString(line) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+
+	return pw.Filter == nil || pw.Filter(line)
+}
+
+// output writes the lines collected in buf, if any.
+func (pw *PrefixWriter) output(buf *bytes.Buffer) {
+	if buf.Len() > 0 {
+		writeOutput(os.Stderr, buf.Bytes())
+	}
+}
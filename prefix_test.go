@@ -0,0 +1,284 @@
+package debuggo
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	// Save original stderr
+	origStderr := os.Stderr
+	defer func() { os.Stderr = origStderr }()
+
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+
+	// Test basic output
+	testPrefix := "TEST-PREFIX"
+	pw := &PrefixWriter{Prefix: testPrefix}
+
+	msg := "Hello, world\n"
+	n, err := pw.Write([]byte(msg))
+
+	if err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+
+	if n != len(msg) {
+		t.Errorf("Expected to write %d bytes, wrote %d", len(msg), n)
+	}
+
+	w.Close()
+
+	// Read output
+	buf := &bytes.Buffer{}
+	_, _ = buf.ReadFrom(r)
+
+	// Check output
+	expected := testPrefix + " " + msg
+	if buf.String() != expected {
+		t.Errorf("Expected output '%s', got '%s'", expected, buf.String())
+	}
+}
+
+func TestPrefixWriterIgnore(t *testing.T) {
+	// Save original stderr
+	origStderr := os.Stderr
+	defer func() { os.Stderr = origStderr }()
+
+	// Test ignored phrases
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+
+	pw := &PrefixWriter{
+		Prefix:  "TEST",
+		Ignores: []string{"ignore me"},
+	}
+
+	// This should be ignored
+	pw.Write([]byte("This text contains ignore me phrase\n"))
+
+	// This should be printed
+	pw.Write([]byte("This text should appear\n"))
+
+	w.Close()
+
+	// Read output
+	buf := &bytes.Buffer{}
+	_, _ = buf.ReadFrom(r)
+
+	// Check output - should only contain the second message
+	if !strings.Contains(buf.String(), "This text should appear") {
+		t.Error("Expected text should appear in output")
+	}
+
+	if strings.Contains(buf.String(), "ignore me") {
+		t.Error("Ignored text should not appear in output")
+	}
+}
+
+// captureStderr runs fn with os.Stderr redirected and returns what it wrote.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+
+	origStderr := os.Stderr
+	defer func() { os.Stderr = origStderr }()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stderr = w
+
+	out := make(chan string)
+	go func() {
+		buf := &bytes.Buffer{}
+		buf.ReadFrom(r)
+		out <- buf.String()
+	}()
+
+	fn()
+	w.Close()
+	return <-out
+}
+
+func TestPrefixWriterLines(t *testing.T) {
+	testCases := []struct {
+		description string
+		writes      []string
+		flush       bool
+		expected    string
+	}{
+		{
+			description: "multiple lines in one write",
+			writes:      []string{"one\ntwo\nthree\n"},
+			expected:    "P one\nP two\nP three\n",
+		},
+		{
+			description: "line split across writes",
+			writes:      []string{"hel", "lo\nwor", "ld\n"},
+			expected:    "P hello\nP world\n",
+		},
+		{
+			description: "partial line is held until flushed",
+			writes:      []string{"done\npartial"},
+			expected:    "P done\n",
+		},
+		{
+			description: "flush writes the partial line",
+			writes:      []string{"done\npartial"},
+			flush:       true,
+			expected:    "P done\nP partial\n",
+		},
+		{
+			description: "CRLF line endings",
+			writes:      []string{"one\r\ntwo\r\n"},
+			expected:    "P one\nP two\n",
+		},
+		{
+			description: "empty lines keep their prefix",
+			writes:      []string{"\n\n"},
+			expected:    "P \nP \n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			got := captureStderr(t, func() {
+				pw := &PrefixWriter{Prefix: "P"}
+				for _, w := range tc.writes {
+					if n, _ := pw.Write([]byte(w)); n != len(w) {
+						t.Errorf("Expected to write %d bytes, wrote %d", len(w), n)
+					}
+				}
+				if tc.flush {
+					pw.Close()
+				}
+			})
+
+			if got != tc.expected {
+				t.Errorf("Expected output %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestPrefixWriterIgnorePerLine(t *testing.T) {
+	got := captureStderr(t, func() {
+		pw := &PrefixWriter{Prefix: "P", Ignores: []string{"/health"}}
+		pw.Write([]byte("GET /health\nGET /users\nGET /hea"))
+		pw.Write([]byte("lth\n"))
+	})
+
+	if got != "P GET /users\n" {
+		t.Errorf("Expected only the /users line, got %q", got)
+	}
+}
+
+func TestPrefixWriterLongLine(t *testing.T) {
+	long := strings.Repeat("x", maxPartialLine+10)
+
+	got := captureStderr(t, func() {
+		pw := &PrefixWriter{Prefix: "P"}
+		pw.Write([]byte(long))
+		pw.Flush()
+	})
+
+	expected := "P " + long[:maxPartialLine] + "\nP " + long[maxPartialLine:] + "\n"
+	if got != expected {
+		t.Errorf("Expected an over-long line to be split, got %d bytes", len(got))
+	}
+}
+
+func TestNewPrefixWriter(t *testing.T) {
+	Enable("app:build")
+	defer Disable()
+
+	buf := &syncBuffer{}
+	SetOutput(buf)
+	defer SetOutput(nil)
+
+	pw := NewPrefixWriter("app:build")
+	pw.Ignores = []string{"noise"}
+	pw.Write([]byte("compiling\nnoise\nlinking"))
+	pw.Flush()
+
+	NewPrefixWriter("app:test").Write([]byte("disabled\n"))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", buf.String())
+	}
+	for i, msg := range []string{"compiling", "linking"} {
+		// Lines are formatted like Debug lines: "15:04:05.000 app:build msg"
+		fields := strings.Fields(lines[i])
+		if len(fields) != 3 || fields[1] != "app:build" || fields[2] != msg {
+			t.Errorf("Expected a formatted %q line, got %q", msg, lines[i])
+		}
+		if _, err := time.Parse(DefaultTimeFormat, fields[0]); err != nil {
+			t.Errorf("Expected a timestamp in %q: %v", lines[i], err)
+		}
+	}
+}
+
+func TestPrefixWriterFilters(t *testing.T) {
+	input := "GET /health 1ms 200\nGET /health 2ms 503\nGET /users 5ms 200\nPOST /users 9ms 201\nDELETE /users/1 3ms 403\n"
+
+	testCases := []struct {
+		description string
+		writer      *PrefixWriter
+		expected    []string
+	}{
+		{
+			description: "ignore pattern keeps failures",
+			writer: &PrefixWriter{
+				IgnorePatterns: []*regexp.Regexp{regexp.MustCompile(`GET /health .* 200`)},
+			},
+			expected: []string{"GET /health 2ms 503", "GET /users 5ms 200", "POST /users 9ms 201", "DELETE /users/1 3ms 403"},
+		},
+		{
+			description: "includes act as an allowlist",
+			writer: &PrefixWriter{
+				Includes: []*regexp.Regexp{regexp.MustCompile(`^POST `), regexp.MustCompile(` [45]\d\d$`)},
+			},
+			expected: []string{"GET /health 2ms 503", "POST /users 9ms 201", "DELETE /users/1 3ms 403"},
+		},
+		{
+			description: "ignores win over includes",
+			writer: &PrefixWriter{
+				Ignores:  []string{"/health"},
+				Includes: []*regexp.Regexp{regexp.MustCompile(` [45]\d\d$`)},
+			},
+			expected: []string{"DELETE /users/1 3ms 403"},
+		},
+		{
+			description: "filter sees each line",
+			writer: &PrefixWriter{
+				Filter: func(line string) bool { return strings.HasPrefix(line, "GET") },
+			},
+			expected: []string{"GET /health 1ms 200", "GET /health 2ms 503", "GET /users 5ms 200"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			got := captureStderr(t, func() {
+				pw := tc.writer
+				pw.Prefix = "P"
+				pw.Write([]byte(input))
+			})
+
+			expected := ""
+			for _, line := range tc.expected {
+				expected += "P " + line + "\n"
+			}
+			if got != expected {
+				t.Errorf("Expected output %q, got %q", expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package debuggo
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// recorder is the active flight recorder, or nil when it is off.
+var recorder atomic.Pointer[flightRecorder]
+
+// flightRecorder keeps the most recent debug lines from every namespace in a
+// fixed-size ring buffer.
+type flightRecorder struct {
+	mu      sync.Mutex
+	entries []recorderEntry
+	next    int
+	full    bool
+}
+
+// recorderEntry is one line in the flight recorder. Lines from enabled
+// namespaces are stored as rendered records; lines from disabled namespaces
+// keep their unrendered message until they are dumped.
+type recorderEntry struct {
+	record Record
+	lazy   message
+	// pending is true when record.Message must be rendered from lazy
+	pending bool
+}
+
+// EnableFlightRecorder starts keeping the last size debug lines in memory,
+// including lines from namespaces that are disabled under DEBUG, so that the
+// run-up to a crash can be inspected with DumpRecent even when debugging was
+// off. Only enabled namespaces are still written to the output. Calling it
+// again replaces the recorder and discards its history; a size below 1 turns
+// the recorder off.
+//
+// Lines from disabled namespaces are not formatted when logged. Their
+// arguments are kept and formatted when the recorder is dumped, which keeps
+// the cost low but means arguments that are later modified are dumped with
+// their new values. Avoid passing values that are mutated concurrently.
+//
+// Example:
+//
+//	debuggo.EnableFlightRecorder(1000)
+//	defer debuggo.DumpOnSignal(os.Stderr, syscall.SIGUSR1)()
+func EnableFlightRecorder(size int) {
+	if size < 1 {
+		recorder.Store(nil)
+		return
+	}
+	recorder.Store(&flightRecorder{entries: make([]recorderEntry, size)})
+}
+
+// activeRecorder returns the flight recorder, or nil if it is off.
+func activeRecorder() *flightRecorder {
+	return recorder.Load()
+}
+
+// DumpRecent writes the lines held by the flight recorder to w, oldest first,
+// using the package formatter. It writes nothing if the recorder is off.
+func DumpRecent(w io.Writer) error {
+	fr := activeRecorder()
+	if fr == nil {
+		return nil
+	}
+
+	_, f := packageSettings()
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+	for _, r := range fr.snapshot() {
+		f.Format(buf, &r)
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// DumpOnSignal dumps the flight recorder to w (the package output if w is
+// nil) every time the process receives one of the given signals, and returns
+// a function that stops listening. Nothing is installed if no signals are
+// given.
+//
+// Example:
+//
+//	stop := debuggo.DumpOnSignal(nil, syscall.SIGUSR1)
+//	defer stop()
+//
+//	// kill -USR1 <pid>
+func DumpOnSignal(w io.Writer, sig ...os.Signal) (stop func()) {
+	if len(sig) == 0 {
+		return func() {}
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sig...)
+
+	go func() {
+		for {
+			select {
+			case <-ch:
+				out := w
+				if out == nil {
+					out, _ = packageSettings()
+				}
+				DumpRecent(out)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// add stores an already rendered record.
+func (fr *flightRecorder) add(r Record) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	fr.push(recorderEntry{record: r})
+}
+
+// addLazy stores a line from a disabled namespace without rendering it.
+func (fr *flightRecorder) addLazy(t time.Time, namespace string, m message, fields []Field) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	fr.push(recorderEntry{
+		record:  Record{Time: t, Namespace: namespace, Fields: fields},
+		lazy:    m,
+		pending: true,
+	})
+}
+
+// push appends e, overwriting the oldest entry when the buffer is full.
+// This must be called with the lock held.
+func (fr *flightRecorder) push(e recorderEntry) {
+	fr.entries[fr.next] = e
+	fr.next++
+	if fr.next == len(fr.entries) {
+		fr.next = 0
+		fr.full = true
+	}
+}
+
+// snapshot returns the recorded lines, oldest first, rendering any that
+// were stored unrendered. Rendering happens outside the lock, so String
+// methods of arguments may log without deadlocking.
+func (fr *flightRecorder) snapshot() []Record {
+	fr.mu.Lock()
+	start, n := 0, fr.next
+	if fr.full {
+		start, n = fr.next, len(fr.entries)
+	}
+	entries := make([]recorderEntry, 0, n)
+	for i := 0; i < n; i++ {
+		entries = append(entries, fr.entries[(start+i)%len(fr.entries)])
+	}
+	fr.mu.Unlock()
+
+	records := make([]Record, len(entries))
+	for i, e := range entries {
+		records[i] = e.record
+		if e.pending {
+			records[i].Message = e.lazy.String()
+			records[i].Fields = redactFields(records[i].Fields)
+		}
+	}
+	return records
+}
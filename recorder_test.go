@@ -0,0 +1,105 @@
+package debuggo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// lazyValue records whether it has been formatted.
+type lazyValue struct {
+	formatted *bool
+}
+
+func (v lazyValue) String() string {
+	*v.formatted = true
+	return "lazy"
+}
+
+func TestFlightRecorderCapturesDisabledNamespaces(t *testing.T) {
+	os.Setenv("DEBUG", "app:api")
+	ReloadDebugSettings()
+
+	EnableFlightRecorder(10)
+	defer EnableFlightRecorder(0)
+
+	live := &bytes.Buffer{}
+	SetOutput(live)
+	defer SetOutput(nil)
+
+	formatted := false
+	Debug("app:api")("live %d", 1)
+	Debug("app:db")("recorded %v", lazyValue{&formatted})
+	New("app:db").Println("println", 2)
+	New("app:cache").WithOutput(live).Write([]byte("written\n"))
+	New("app:db").PrintfContext(WithContext(context.Background(), F("id", 7)), "with fields")
+
+	if formatted {
+		t.Error("Expected disabled lines not to be formatted until dumped")
+	}
+	if strings.Count(live.String(), "\n") != 1 || !strings.Contains(live.String(), "app:api live 1") {
+		t.Errorf("Expected only the enabled namespace on the live output, got %q", live.String())
+	}
+
+	dump := &bytes.Buffer{}
+	if err := DumpRecent(dump); err != nil {
+		t.Fatalf("DumpRecent failed: %v", err)
+	}
+
+	expected := []string{
+		" app:api live 1",
+		" app:db recorded lazy",
+		" app:db println 2",
+		" app:cache written",
+		" app:db with fields id=7",
+	}
+	lines := strings.Split(strings.TrimSuffix(dump.String(), "\n"), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines, got %q", len(expected), dump.String())
+	}
+	for i, want := range expected {
+		if !strings.HasSuffix(lines[i], want) {
+			t.Errorf("Expected line %d to end with %q, got %q", i, want, lines[i])
+		}
+	}
+}
+
+func TestFlightRecorderKeepsMostRecent(t *testing.T) {
+	os.Setenv("DEBUG", "")
+	ReloadDebugSettings()
+
+	EnableFlightRecorder(3)
+	defer EnableFlightRecorder(0)
+
+	debug := Debug("app")
+	for i := 1; i <= 5; i++ {
+		debug("line %d", i)
+	}
+
+	dump := &bytes.Buffer{}
+	DumpRecent(dump)
+
+	lines := strings.Split(strings.TrimSuffix(dump.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %q", dump.String())
+	}
+	for i, line := range lines {
+		if want := fmt.Sprintf(" app line %d", i+3); !strings.HasSuffix(line, want) {
+			t.Errorf("Expected line %d to end with %q, got %q", i, want, line)
+		}
+	}
+}
+
+func TestFlightRecorderOff(t *testing.T) {
+	EnableFlightRecorder(0)
+
+	Debug("app")("not recorded")
+
+	dump := &bytes.Buffer{}
+	if err := DumpRecent(dump); err != nil || dump.Len() != 0 {
+		t.Errorf("Expected an empty dump with the recorder off, got %q, %v", dump.String(), err)
+	}
+}
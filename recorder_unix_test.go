@@ -0,0 +1,35 @@
+//go:build unix
+
+package debuggo
+
+import (
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestDumpOnSignal(t *testing.T) {
+	os.Setenv("DEBUG", "")
+	ReloadDebugSettings()
+
+	EnableFlightRecorder(10)
+	defer EnableFlightRecorder(0)
+
+	Debug("app:db")("before the signal")
+
+	out := &syncBuffer{}
+	stop := DumpOnSignal(out, syscall.SIGUSR1)
+	defer stop()
+
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(out.String(), "app:db before the signal") {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected a dump after the signal, got %q", out.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,478 @@
+package debuggo
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// RedactedText replaces redacted values when a Redaction has no Replacement,
+// and is printed in place of struct fields tagged `debug:"redact"`.
+const RedactedText = "[REDACTED]"
+
+// Redaction masks every match of Pattern in debug output.
+type Redaction struct {
+	// Pattern matches the text to mask
+	Pattern *regexp.Regexp
+	// Replacement replaces each match, with $1-style group expansion as in
+	// regexp.Regexp.ReplaceAllString. Defaults to RedactedText.
+	Replacement string
+
+	// validate, when set, must accept a match before it is replaced
+	validate func(match string) bool
+}
+
+// Built-in redactions for common secrets, for use with SetRedactions.
+var (
+	// RedactBearerTokens masks the token in "Bearer <token>"
+	RedactBearerTokens = Redaction{
+		Pattern:     regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9\-._~+/]+=*`),
+		Replacement: "${1}" + RedactedText,
+	}
+	// RedactAWSKeys masks AWS access key IDs and secret access keys assigned
+	// to aws_secret_access_key
+	RedactAWSKeys = Redaction{
+		Pattern:     regexp.MustCompile(`(?i:(aws_secret_access_key["']?\s*[:=]\s*["']?))[A-Za-z0-9/+]{40}|\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+		Replacement: "${1}" + RedactedText,
+	}
+	// RedactCreditCards masks 13 to 19 digit card numbers, optionally
+	// separated by spaces or dashes, that pass the Luhn check
+	RedactCreditCards = Redaction{
+		Pattern:  regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		validate: luhnValid,
+	}
+	// RedactEmails masks email addresses
+	RedactEmails = Redaction{
+		Pattern: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+	}
+)
+
+// redactions holds the redactions installed by SetRedactions.
+var redactions atomic.Pointer[[]Redaction]
+
+// SetRedactions replaces the redactions applied to every debug message and
+// its field values, including lines written through a PrefixWriter and lines
+// kept by the flight recorder. Calling it with no arguments turns regex
+// redaction off. It returns the previous redactions so that they can be
+// restored later.
+//
+// Struct fields tagged `debug:"redact"` are always printed as RedactedText
+// when a struct is formatted into a message or field value, whether or not
+// any redactions are set. Tagged fields are found in structs passed directly
+// or by pointer, in nested structs and in slices and arrays of them, but not
+// inside maps, interface values or values with their own String or Format
+// methods.
+//
+// Example:
+//
+//	debuggo.SetRedactions(
+//	    debuggo.RedactBearerTokens,
+//	    debuggo.RedactAWSKeys,
+//	    debuggo.Redaction{Pattern: regexp.MustCompile(`password=\S+`)},
+//	)
+func SetRedactions(r ...Redaction) (previous []Redaction) {
+	var next *[]Redaction
+	if len(r) > 0 {
+		r = append([]Redaction(nil), r...)
+		next = &r
+	}
+	if prev := redactions.Swap(next); prev != nil {
+		previous = *prev
+	}
+	return previous
+}
+
+// redactText applies the installed redactions to s.
+func redactText(s string) string {
+	rs := redactions.Load()
+	if rs == nil {
+		return s
+	}
+
+	for _, r := range *rs {
+		if r.Pattern == nil {
+			continue
+		}
+		s = r.apply(s)
+	}
+	return s
+}
+
+// redactedSpans returns the [start, end) byte ranges of s matched by the
+// installed redactions, for output that cannot be rewritten as text.
+func redactedSpans(s string) [][]int {
+	rs := redactions.Load()
+	if rs == nil {
+		return nil
+	}
+
+	var spans [][]int
+	for _, r := range *rs {
+		if r.Pattern == nil {
+			continue
+		}
+		for _, m := range r.Pattern.FindAllStringIndex(s, -1) {
+			if r.validate == nil || r.validate(s[m[0]:m[1]]) {
+				spans = append(spans, m)
+			}
+		}
+	}
+	return spans
+}
+
+// apply masks the matches of r in s.
+func (r Redaction) apply(s string) string {
+	replacement := r.Replacement
+	if replacement == "" {
+		replacement = RedactedText
+	}
+
+	if r.validate == nil {
+		return r.Pattern.ReplaceAllString(s, replacement)
+	}
+	return r.Pattern.ReplaceAllStringFunc(s, func(match string) string {
+		if !r.validate(match) {
+			return match
+		}
+		return r.Pattern.ReplaceAllString(match, replacement)
+	})
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum.
+func luhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// redactArgs wraps arguments containing struct fields tagged
+// `debug:"redact"` so that those fields are masked when formatted. It returns
+// args itself when nothing needs wrapping.
+func redactArgs(args []interface{}) []interface{} {
+	return redactArgsExcept(args, nil)
+}
+
+// redactPrintfArgs is redactArgs for the arguments of format. Arguments
+// printed with %T or %p are left unwrapped, since fmt prints those verbs
+// without consulting the value's Format method.
+func redactPrintfArgs(format string, args []interface{}) []interface{} {
+	return redactArgsExcept(args, describedArgs(format, len(args)))
+}
+
+func redactArgsExcept(args []interface{}, skip []bool) []interface{} {
+	var wrapped []interface{}
+	for i, arg := range args {
+		v := reflect.ValueOf(arg)
+		if (skip != nil && skip[i]) || !needsRedaction(v) {
+			continue
+		}
+		if wrapped == nil {
+			wrapped = append([]interface{}(nil), args...)
+		}
+		wrapped[i] = redactedValue{v}
+	}
+	if wrapped == nil {
+		return args
+	}
+	return wrapped
+}
+
+// describedArgs reports which of n arguments format prints with %T or %p,
+// following fmt's rules for flags, '*' widths and explicit [n] indexes. It
+// returns nil when there are none.
+func describedArgs(format string, n int) []bool {
+	var described []bool
+	arg := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+	flags:
+		for i++; i < len(format); i++ {
+			c := format[i]
+			switch {
+			case c == '*':
+				arg++
+				continue
+			case c == '[':
+				end := strings.IndexByte(format[i:], ']')
+				if end < 0 {
+					return described
+				}
+				if idx, err := strconv.Atoi(format[i+1 : i+end]); err == nil {
+					arg = idx - 1
+				}
+				i += end
+				continue
+			case strings.IndexByte("+-# 0.", c) >= 0 || ('0' <= c && c <= '9'):
+				continue
+			}
+			break flags
+		}
+		if i >= len(format) {
+			break
+		}
+		if format[i] == '%' {
+			continue
+		}
+		if (format[i] == 'T' || format[i] == 'p') && arg >= 0 && arg < n {
+			if described == nil {
+				described = make([]bool, n)
+			}
+			described[arg] = true
+		}
+		arg++
+	}
+	return described
+}
+
+// redactFields returns fields with redactions applied to their values. It
+// returns fields itself when nothing needs masking, and a copy otherwise, as
+// the slice may be shared with a context.
+func redactFields(fields []Field) []Field {
+	var redacted []Field
+	for i, field := range fields {
+		v, ok := redactFieldValue(field.Value)
+		if !ok {
+			continue
+		}
+		if redacted == nil {
+			redacted = append([]Field(nil), fields...)
+		}
+		redacted[i].Value = v
+	}
+	if redacted == nil {
+		return fields
+	}
+	return redacted
+}
+
+// redactFieldValue returns the text of v with redactions applied, reporting
+// false when v can be kept as it is. Masked values become strings, which
+// every formatter prints the same way.
+func redactFieldValue(v interface{}) (interface{}, bool) {
+	rv := reflect.ValueOf(v)
+	tagged := needsRedaction(rv)
+	if !tagged && redactions.Load() == nil {
+		return v, false
+	}
+
+	var s string
+	if tagged {
+		s = fmt.Sprint(redactedValue{rv})
+	} else {
+		s = fmt.Sprint(v)
+	}
+	redacted := redactText(s)
+	if !tagged && redacted == s {
+		return v, false
+	}
+	return redacted, true
+}
+
+// needsRedaction reports whether formatting v would print a tagged field.
+// Values that format themselves are left alone.
+func needsRedaction(v reflect.Value) bool {
+	if !v.IsValid() {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Array:
+	case reflect.Pointer:
+		// fmt only follows pointers at the top level
+		if v.IsNil() || v.Type().Elem().Kind() != reflect.Struct {
+			return false
+		}
+	default:
+		return false
+	}
+	if v.CanInterface() && formatsItself(v.Interface()) {
+		return false
+	}
+	return typeHasRedactedFields(v.Type())
+}
+
+// formatsItself reports whether fmt would print v using its own methods.
+func formatsItself(v interface{}) bool {
+	switch v.(type) {
+	case fmt.Formatter, fmt.Stringer, error:
+		return true
+	}
+	return false
+}
+
+// redactedTypes caches typeHasRedactedFields by type.
+var redactedTypes sync.Map
+
+// typeHasRedactedFields reports whether values of t contain a tagged field
+// that fmt would print, looking through the top-level pointer, nested
+// structs, slices and arrays.
+func typeHasRedactedFields(t reflect.Type) bool {
+	if cached, ok := redactedTypes.Load(t); ok {
+		return cached.(bool)
+	}
+	// Assume false while t is being inspected, which ends recursion on
+	// self-referencing types
+	redactedTypes.Store(t, false)
+
+	found := false
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		found = typeHasRedactedFields(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField() && !found; i++ {
+			f := t.Field(i)
+			found = isRedactTag(f.Tag) || typeHasRedactedFields(f.Type)
+		}
+	}
+
+	redactedTypes.Store(t, found)
+	return found
+}
+
+// isRedactTag reports whether a struct tag marks its field for redaction.
+func isRedactTag(tag reflect.StructTag) bool {
+	opts := strings.Split(tag.Get("debug"), ",")
+	for _, opt := range opts {
+		if opt == "redact" {
+			return true
+		}
+	}
+	return false
+}
+
+// redactedValue formats a struct like fmt does, but prints RedactedText in
+// place of tagged fields.
+type redactedValue struct {
+	v reflect.Value
+}
+
+// Format implements fmt.Formatter.
+func (r redactedValue) Format(f fmt.State, verb rune) {
+	r.format(f, verb, r.v, true)
+}
+
+func (r redactedValue) format(f fmt.State, verb rune, v reflect.Value, top bool) {
+	plus, sharp := f.Flag('+'), f.Flag('#')
+
+	if !top && v.Kind() == reflect.Pointer {
+		// Nested pointers are printed as addresses, as fmt does; passing
+		// them to fmt would print the value they point to instead
+		switch {
+		case sharp && v.IsNil():
+			fmt.Fprintf(f, "(%s)(nil)", v.Type())
+		case sharp:
+			fmt.Fprintf(f, "(%s)(%#x)", v.Type(), v.Pointer())
+		case v.IsNil():
+			f.Write([]byte("<nil>"))
+		default:
+			fmt.Fprintf(f, "%#x", v.Pointer())
+		}
+		return
+	}
+	if !needsRedaction(v) {
+		fmt.Fprintf(f, fmtDirective(f, verb), v)
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		f.Write([]byte("&"))
+		r.format(f, verb, v.Elem(), false)
+	case reflect.Slice, reflect.Array:
+		if sharp {
+			f.Write([]byte(v.Type().String()))
+		}
+		f.Write([]byte(openBracket(sharp)))
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				f.Write([]byte(separator(sharp)))
+			}
+			r.format(f, verb, v.Index(i), false)
+		}
+		f.Write([]byte(closeBracket(sharp)))
+	case reflect.Struct:
+		if sharp {
+			f.Write([]byte(v.Type().String()))
+		}
+		f.Write([]byte("{"))
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if i > 0 {
+				f.Write([]byte(separator(sharp)))
+			}
+			if plus || sharp {
+				f.Write([]byte(t.Field(i).Name + ":"))
+			}
+			if isRedactTag(t.Field(i).Tag) {
+				if sharp {
+					fmt.Fprintf(f, "%q", RedactedText)
+				} else {
+					f.Write([]byte(RedactedText))
+				}
+				continue
+			}
+			r.format(f, verb, v.Field(i), false)
+		}
+		f.Write([]byte("}"))
+	}
+}
+
+// fmtDirective rebuilds the formatting directive for verb from f's flags,
+// so nested values are printed as they would be without redaction.
+func fmtDirective(f fmt.State, verb rune) string {
+	var b strings.Builder
+	b.WriteByte('%')
+	for _, flag := range "+-# 0" {
+		if f.Flag(int(flag)) {
+			b.WriteRune(flag)
+		}
+	}
+	if w, ok := f.Width(); ok {
+		fmt.Fprint(&b, w)
+	}
+	if p, ok := f.Precision(); ok {
+		fmt.Fprintf(&b, ".%d", p)
+	}
+	b.WriteRune(verb)
+	return b.String()
+}
+
+func openBracket(sharp bool) string {
+	if sharp {
+		return "{"
+	}
+	return "["
+}
+
+func closeBracket(sharp bool) string {
+	if sharp {
+		return "}"
+	}
+	return "]"
+}
+
+func separator(sharp bool) string {
+	if sharp {
+		return ", "
+	}
+	return " "
+}
@@ -0,0 +1,169 @@
+package debuggo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// spanIndent is repeated once per nesting level in front of span lines.
+const spanIndent = "  "
+
+// noopEnd is returned by Span when its namespace is disabled.
+var noopEnd = func() {}
+
+var (
+	// spanDepths counts the open spans of each goroutine
+	spanDepths   = map[uint64]int{}
+	spanDepthsMu sync.Mutex
+
+	// timers holds the start times recorded by Logger.Time
+	timers   = map[timerKey]time.Time{}
+	timersMu sync.Mutex
+)
+
+// timerKey identifies a timer started with Logger.Time.
+type timerKey struct {
+	namespace string
+	label     string
+}
+
+// spanDepthKey is the context key holding the nesting depth of SpanContext.
+type spanDepthKey struct{}
+
+// Span logs the start of an operation under namespace and returns a function
+// that logs its end along with the time taken. It is meant to be deferred:
+//
+//	defer debuggo.Span("app:db", "query %s", name)()
+//
+// Spans opened on the same goroutine while another is still open are
+// indented under it:
+//
+//	12:34:56.789 app:db begin load user 42
+//	12:34:56.790 app:db   begin query users
+//	12:34:56.802 app:db   end query users (12.1ms)
+//	12:34:56.803 app:db end load user 42 (14.2ms)
+//
+// When namespace is disabled nothing is timed or logged, and the returned
+// function does nothing.
+func Span(namespace, format string, args ...interface{}) (end func()) {
+	return New(namespace).Span(format, args...)
+}
+
+// Span is the Logger equivalent of the package-level Span function.
+func (l *Logger) Span(format string, args ...interface{}) (end func()) {
+	if !IsEnabled(l.namespace) {
+		return noopEnd
+	}
+	if h := l.helper(); h != nil {
+		h.Helper()
+	}
+
+	gid := goroutineID()
+	spanDepthsMu.Lock()
+	depth := spanDepths[gid]
+	spanDepths[gid] = depth + 1
+	spanDepthsMu.Unlock()
+
+	return l.span(nil, depth, format, args, func() {
+		spanDepthsMu.Lock()
+		defer spanDepthsMu.Unlock()
+		if spanDepths[gid] <= 1 {
+			delete(spanDepths, gid)
+		} else {
+			spanDepths[gid]--
+		}
+	})
+}
+
+// SpanContext is like Span, but tracks nesting through ctx rather than the
+// calling goroutine, so spans nest correctly across goroutines. Pass the
+// returned context to nested calls; lines are logged with its fields as by
+// PrintfContext.
+//
+// Example:
+//
+//	ctx, end := logger.SpanContext(ctx, "handle %s", r.URL.Path)
+//	defer end()
+func (l *Logger) SpanContext(ctx context.Context, format string, args ...interface{}) (context.Context, func()) {
+	if !IsEnabled(l.namespace) {
+		return ctx, noopEnd
+	}
+	if h := l.helper(); h != nil {
+		h.Helper()
+	}
+
+	depth, _ := ctx.Value(spanDepthKey{}).(int)
+	return context.WithValue(ctx, spanDepthKey{}, depth+1), l.span(ctx, depth, format, args, nil)
+}
+
+// span logs the begin line of a span at the given depth and returns the
+// function that logs its end line, once, after calling onEnd if set.
+func (l *Logger) span(ctx context.Context, depth int, format string, args []interface{}, onEnd func()) func() {
+	if h := l.helper(); h != nil {
+		h.Helper()
+	}
+
+	// Render once so the begin and end lines match even if args change
+	msg := message{format: format, args: args}.render()
+	indent := strings.Repeat(spanIndent, depth)
+	start := time.Now()
+
+	l.log(ctx, message{format: indent + "begin " + msg, mode: modeText})
+
+	var once sync.Once
+	return func() {
+		if h := l.helper(); h != nil {
+			h.Helper()
+		}
+		once.Do(func() {
+			if onEnd != nil {
+				onEnd()
+			}
+			text := fmt.Sprintf("%send %s (%s)", indent, msg, time.Since(start))
+			l.log(ctx, message{format: text, mode: modeText})
+		})
+	}
+}
+
+// Time starts a timer with the given label, like console.time in
+// JavaScript. Call TimeEnd with the same label to log the time elapsed.
+// Starting a timer that is already running restarts it. Nothing is recorded
+// when the logger's namespace is disabled.
+//
+// Example:
+//
+//	logger.Time("load config")
+//	cfg := loadConfig()
+//	logger.TimeEnd("load config") // load config: 3.2ms
+func (l *Logger) Time(label string) {
+	if !IsEnabled(l.namespace) {
+		return
+	}
+
+	timersMu.Lock()
+	defer timersMu.Unlock()
+	timers[timerKey{l.namespace, label}] = time.Now()
+}
+
+// TimeEnd stops the timer started by Time with the same label and logs the
+// time elapsed. It does nothing if no such timer is running, such as when
+// the namespace was disabled when Time was called.
+func (l *Logger) TimeEnd(label string) {
+	if h := l.helper(); h != nil {
+		h.Helper()
+	}
+
+	key := timerKey{l.namespace, label}
+	timersMu.Lock()
+	start, ok := timers[key]
+	delete(timers, key)
+	timersMu.Unlock()
+
+	if !ok {
+		return
+	}
+	l.log(nil, message{format: fmt.Sprintf("%s: %s", label, time.Since(start)), mode: modeText})
+}
@@ -0,0 +1,149 @@
+package debuggo
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// spanOutput captures messages from namespace while fn runs.
+func spanOutput(t *testing.T, fn func(l *Logger)) []string {
+	t.Helper()
+
+	buf := &syncBuffer{}
+	fn(New("app:span").WithOutput(buf).WithFormatter(messageOnly{}))
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+}
+
+// assertMatches checks that lines match patterns one to one.
+func assertMatches(t *testing.T, lines, patterns []string) {
+	t.Helper()
+
+	if len(lines) != len(patterns) {
+		t.Fatalf("Expected %d lines, got %q", len(patterns), lines)
+	}
+	for i, pattern := range patterns {
+		if !regexp.MustCompile(pattern).MatchString(lines[i]) {
+			t.Errorf("Expected line %d to match %q, got %q", i, pattern, lines[i])
+		}
+	}
+}
+
+func TestSpanNesting(t *testing.T) {
+	Enable("app:*")
+	defer Disable()
+
+	lines := spanOutput(t, func(l *Logger) {
+		outer := l.Span("load user %d", 42)
+		inner := l.Span("query %s", "users")
+		inner()
+		inner() // ending twice logs once
+		l.Span("query %s", "roles")()
+		outer()
+		l.Span("after")()
+	})
+
+	assertMatches(t, lines, []string{
+		`^begin load user 42$`,
+		`^  begin query users$`,
+		`^  end query users \(\S+\)$`,
+		`^  begin query roles$`,
+		`^  end query roles \(\S+\)$`,
+		`^end load user 42 \(\S+\)$`,
+		`^begin after$`,
+		`^end after \(\S+\)$`,
+	})
+}
+
+func TestSpanPerGoroutine(t *testing.T) {
+	Enable("app:*")
+	defer Disable()
+
+	lines := spanOutput(t, func(l *Logger) {
+		defer l.Span("outer")()
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Span("other goroutine")()
+		}()
+		wg.Wait()
+	})
+
+	if lines[1] != "begin other goroutine" {
+		t.Errorf("Expected spans on another goroutine not to be indented, got %q", lines[1])
+	}
+}
+
+func TestSpanContext(t *testing.T) {
+	Enable("app:*")
+	defer Disable()
+
+	lines := spanOutput(t, func(l *Logger) {
+		ctx, end := l.SpanContext(WithContext(context.Background(), F("req", 1)), "handle")
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, end := l.SpanContext(ctx, "worker")
+			end()
+		}()
+		wg.Wait()
+		end()
+	})
+
+	assertMatches(t, lines, []string{
+		`^begin handle$`,
+		`^  begin worker$`,
+		`^  end worker \(\S+\)$`,
+		`^end handle \(\S+\)$`,
+	})
+}
+
+func TestSpanDisabled(t *testing.T) {
+	Enable("app:other")
+	defer Disable()
+
+	buf := &syncBuffer{}
+	l := New("app:span").WithOutput(buf)
+
+	// A disabled span should cost no more than the IsEnabled check itself
+	check := testing.AllocsPerRun(100, func() {
+		IsEnabled("app:span")
+	})
+	allocs := testing.AllocsPerRun(100, func() {
+		l.Span("disabled")()
+	})
+	if allocs > check {
+		t.Errorf("Expected a disabled span to allocate at most %v times, got %v", check, allocs)
+	}
+
+	ctx := context.Background()
+	if got, end := l.SpanContext(ctx, "disabled"); got != ctx {
+		t.Error("Expected the context to be returned unchanged")
+	} else {
+		end()
+	}
+
+	if buf.String() != "" {
+		t.Errorf("Expected no output, got %q", buf.String())
+	}
+}
+
+func TestTimeEnd(t *testing.T) {
+	Enable("app:*")
+	defer Disable()
+
+	lines := spanOutput(t, func(l *Logger) {
+		l.Time("load")
+		l.TimeEnd("load")
+		l.TimeEnd("load") // no longer running
+		l.TimeEnd("never started")
+	})
+
+	assertMatches(t, lines, []string{`^load: \S+$`})
+}
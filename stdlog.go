@@ -0,0 +1,42 @@
+package debuggo
+
+import (
+	"log"
+)
+
+// NewStdLogger returns a standard library *log.Logger that writes each
+// message as a debug line of namespace. It has no prefix or flags of its
+// own, so lines carry only debuggo's timestamp and namespace, and nothing is
+// written unless namespace is enabled under DEBUG.
+//
+// Example:
+//
+//	srv := &http.Server{
+//	    Addr:     ":8080",
+//	    ErrorLog: debuggo.NewStdLogger("app:server:http"),
+//	}
+func NewStdLogger(namespace string) *log.Logger {
+	return log.New(New(namespace), "", 0)
+}
+
+// RedirectStdLog sends the output of the standard library's default logger
+// (log.Printf and friends) to namespace, clearing its prefix and flags, and
+// returns a function that restores the previous output, prefix and flags.
+//
+// Example:
+//
+//	undo := debuggo.RedirectStdLog("app:log")
+//	defer undo()
+func RedirectStdLog(namespace string) (undo func()) {
+	prevOutput, prevPrefix, prevFlags := log.Writer(), log.Prefix(), log.Flags()
+
+	log.SetOutput(New(namespace))
+	log.SetPrefix("")
+	log.SetFlags(0)
+
+	return func() {
+		log.SetOutput(prevOutput)
+		log.SetPrefix(prevPrefix)
+		log.SetFlags(prevFlags)
+	}
+}
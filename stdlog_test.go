@@ -0,0 +1,71 @@
+package debuggo
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNewStdLogger(t *testing.T) {
+	Enable("app:http")
+	defer Disable()
+
+	buf := &syncBuffer{}
+	SetOutput(buf)
+	defer SetOutput(nil)
+
+	NewStdLogger("app:http").Printf("http: TLS handshake error from %s", "10.0.0.1")
+	NewStdLogger("app:other").Print("disabled")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected 1 line, got %q", buf.String())
+	}
+	if !strings.HasSuffix(lines[0], " app:http http: TLS handshake error from 10.0.0.1") {
+		t.Errorf("Expected a debug line without std log prefix or flags, got %q", lines[0])
+	}
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	Enable("app:log")
+	defer Disable()
+
+	buf := &syncBuffer{}
+	SetOutput(buf)
+	defer SetOutput(nil)
+
+	orig := &bytes.Buffer{}
+	log.SetOutput(orig)
+	log.SetPrefix("[std] ")
+	log.SetFlags(log.LstdFlags)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetPrefix("")
+		log.SetFlags(log.LstdFlags)
+	}()
+
+	undo := RedirectStdLog("app:log")
+	log.Println("redirected")
+
+	if !strings.Contains(buf.String(), " app:log redirected\n") {
+		t.Errorf("Expected std log output as a debug line, got %q", buf.String())
+	}
+	if strings.Contains(buf.String(), "[std]") {
+		t.Errorf("Expected the std log prefix to be cleared, got %q", buf.String())
+	}
+	if orig.Len() != 0 {
+		t.Errorf("Expected nothing in the previous output, got %q", orig.String())
+	}
+
+	undo()
+	log.Println("restored")
+
+	if log.Prefix() != "[std] " || log.Flags() != log.LstdFlags {
+		t.Errorf("Expected prefix and flags to be restored, got %q and %d", log.Prefix(), log.Flags())
+	}
+	if !strings.HasPrefix(orig.String(), "[std] ") || !strings.HasSuffix(orig.String(), "restored\n") {
+		t.Errorf("Expected output to be restored, got %q", orig.String())
+	}
+}